@@ -30,6 +30,7 @@ type Api struct {
 	Status       string       `json:"status,omitempty"`
 	SourceId     string       `json:"source" db:"source_id"`
 	QueryContent QueryContent `json:"queryContent" db:"queryContent"`
+	Policy       ExportPolicy `json:"policy" db:"-"`
 }
 
 // Database information (= source) format to load from internal databse
@@ -50,35 +51,159 @@ type QueryContent struct {
 	DidOptions  string        `json:"didOptions,omitempty"`
 }
 
+// export policy format (stored with API definition)
+type ExportPolicy struct {
+	UnconfiguredColumn string  `json:"unconfiguredColumn,omitempty"` // process for columns without de-identification options: pass, drop, reject (default: DID_STRICT_MODE)
+	KValue             int     `json:"kValue,omitempty"`             // k-anonymity target (default: 2)
+	Enforcement        string  `json:"enforcement,omitempty"`        // report (default: evaluate after streaming), block (evaluate before any data is released)
+	LValue             int     `json:"lValue,omitempty"`             // distinct l-diversity target for sensitive columns (0: not evaluated)
+	EntropyLValue      float64 `json:"entropyLValue,omitempty"`      // entropy l-diversity target (0: not evaluated)
+	TValue             float64 `json:"tValue,omitempty"`             // t-closeness target (maximum EMD, 0: not evaluated)
+	SmallClass         string  `json:"smallClass,omitempty"`         // process for rows in classes smaller than k: keep (default), suppress, generalize (by fallback options, suppress if still smaller than k)
+}
+
 // evaluation result format for k-anonymity
 type Evaluation struct {
-	ApiName string `json:"apiName"`
-	Result  string `json:"result"`
-	Value   int64  `json:"value"`
+	ApiName                   string           `json:"apiName"`
+	Result                    string           `json:"result"`
+	Value                     int64            `json:"value"`
+	Target                    int64            `json:"target"`
+	LDiversity                int64            `json:"lDiversity,omitempty"`
+	EntropyLDiversity         float64          `json:"entropyLDiversity,omitempty"`
+	TCloseness                float64          `json:"tCloseness,omitempty"`
+	Failed                    []string         `json:"failed,omitempty"` // criteria that are not met (k, l, entropyL, t)
+	ErrorCount                int64            `json:"errorCount"`
+	ColumnErrors              map[string]int64 `json:"columnErrors,omitempty"`
+	ErrorSuppressedRows       int64            `json:"errorSuppressedRows"`
+	SmallClassSuppressedRows  int64            `json:"smallClassSuppressedRows"`
+	SmallClassGeneralizedRows int64            `json:"smallClassGeneralizedRows,omitempty"`
+	Risk                      *RiskReport      `json:"risk,omitempty"` // re-identification risk (only for risk analysis)
+}
+
+// re-identification risk report format
+type RiskReport struct {
+	ApiName            string         `json:"apiName"`
+	Records            int64          `json:"records"`
+	Classes            int64          `json:"classes"`          // number of equivalence classes
+	SamplingFraction   float64        `json:"samplingFraction"` // fraction of population in the data (for journalist, marketer risk)
+	ProsecutorRisk     RiskMeasure    `json:"prosecutorRisk"`
+	JournalistRisk     RiskMeasure    `json:"journalistRisk"`
+	MarketerRisk       float64        `json:"marketerRisk"`
+	UniqueRecords      int64          `json:"uniqueRecords"`
+	UniqueShare        float64        `json:"uniqueShare"`
+	ClassSizes         []ClassSizeBin `json:"classSizes"`         // histogram of equivalence class sizes
+	HighestRiskRecords []RiskRecord   `json:"highestRiskRecords"` // records in the smallest classes
+}
+
+type RiskMeasure struct {
+	Highest float64 `json:"highest"`
+	Average float64 `json:"average"`
+}
+
+type ClassSizeBin struct {
+	Size    int64 `json:"size"`
+	Classes int64 `json:"classes"`
+	Records int64 `json:"records"`
+}
+
+type RiskRecord struct {
+	Row       int64   `json:"row"` // sequence number of row in query result (0-based, order of query result)
+	ClassSize int64   `json:"classSize"`
+	Risk      float64 `json:"risk"`
 }
 
 /* De-identification Process */
 // AnoOption defines the specific anonymization option parameter format
 type AnoOption struct {
-	Fore       string `json:"fore,omitempty"`
-	Aft        string `json:"aft,omitempty"`
-	MaskChar   string `json:"maskChar,omitempty"`
-	KeepLength string `json:"keepLength,omitempty"`
-	Algorithm  string `json:"algorithm,omitempty"`
-	Position   int    `json:"position,omitempty"`
-	Unit       string `json:"unit,omitempty"`
-	Key        string `json:"key,omitempty"`
-	Digest     string `json:"digest,omitempty"`
-	Lower      string `json:"lower,omitempty"`
-	Upper      string `json:"upper,omitempty"`
-	Bin        string `json:"bin,omitempty"`
-	Linear     string `json:"linear,omitempty"`
+	Fore          string    `json:"fore,omitempty"`
+	Aft           string    `json:"aft,omitempty"`
+	MaskChar      string    `json:"maskChar,omitempty"`
+	KeepLength    string    `json:"keepLength,omitempty"`
+	Percent       string    `json:"percent,omitempty"`
+	Algorithm     string    `json:"algorithm,omitempty"`
+	Position      int       `json:"position,omitempty"`
+	Unit          string    `json:"unit,omitempty"`
+	Format        string    `json:"format,omitempty"`
+	Key           string    `json:"key,omitempty"`
+	KeyRef        string    `json:"keyRef,omitempty"`
+	Mode          string    `json:"mode,omitempty"`
+	Alphabet      string    `json:"alphabet,omitempty"`
+	Tweak         string    `json:"tweak,omitempty"`
+	Domain        string    `json:"domain,omitempty"`
+	Length        string    `json:"length,omitempty"`
+	Digest        string    `json:"digest,omitempty"`
+	Lower         string    `json:"lower,omitempty"`
+	Upper         string    `json:"upper,omitempty"`
+	Bin           string    `json:"bin,omitempty"`
+	Boundaries    []string  `json:"boundaries,omitempty"`
+	Labels        []string  `json:"labels,omitempty"`
+	Hierarchy     string    `json:"hierarchy,omitempty"`
+	Ipv4Prefix    string    `json:"ipv4Prefix,omitempty"`
+	Ipv6Prefix    string    `json:"ipv6Prefix,omitempty"`
+	MacPrefix     string    `json:"macPrefix,omitempty"`
+	Precision     string    `json:"precision,omitempty"`
+	CellSize      string    `json:"cellSize,omitempty"`
+	Axis          string    `json:"axis,omitempty"`
+	PairColumn    string    `json:"pairColumn,omitempty"`
+	Attribute     string    `json:"attribute,omitempty"`
+	ReferenceDate string    `json:"referenceDate,omitempty"`
+	Dictionary    string    `json:"dictionary,omitempty"`
+	Window        string    `json:"window,omitempty"`
+	Pii           []PiiRule `json:"pii,omitempty"`
+	TopLabel      string    `json:"topLabel,omitempty"`
+	BottomLabel   string    `json:"bottomLabel,omitempty"`
+	Linear        string    `json:"linear,omitempty"`
+	Epsilon       string    `json:"epsilon,omitempty"`
+	Delta         string    `json:"delta,omitempty"`
+	Sensitivity   string    `json:"sensitivity,omitempty"`
+	Seed          string    `json:"seed,omitempty"`
+
+	// nested options for each JSONPath (json_fields)
+	Fields map[string]AnoParamOption `json:"fields,omitempty"`
 }
 
 // Option defines the field anonymization method parameter format
 type AnoParamOption struct {
-	Method      string    `json:"method"`
-	Options     AnoOption `json:"options"`
-	Level       int       `json:"level"`
-	Description string    `json:"description"`
+	Method      string            `json:"method"`
+	Options     AnoOption         `json:"options"`
+	Level       int               `json:"level"`
+	Description string            `json:"description"`
+	Pipeline    []AnoMethodOption `json:"pipeline,omitempty"`
+	OnError     string            `json:"onError,omitempty"`     // policy for values that can't be processed: null (default), suppress, replace, abort
+	Replacement string            `json:"replacement,omitempty"` // value for replace policy
+	KeyColumn   string            `json:"keyColumn,omitempty"`   // column that identifies the subject of the row (date_shift)
+	Role        string            `json:"role,omitempty"`        // role for k-anonymity evaluation: quasi_identifier (default), sensitive, identifier, insensitive
+	Fallback    []AnoMethodOption `json:"fallback,omitempty"`    // coarser generalization for rows in classes smaller than k (smallClass: generalize)
+}
+
+// AnoMethodOption defines a step of the field anonymization pipeline (applied in order)
+type AnoMethodOption struct {
+	Method  string    `json:"method"`
+	Options AnoOption `json:"options"`
+	Level   int       `json:"level"`
+}
+
+// Generalization hierarchy format (each node has the value of its parent, root node has no parent)
+type Hierarchy struct {
+	Name  string          `json:"name" db:"hierarchy_name"`
+	Nodes []HierarchyNode `json:"nodes"`
+}
+
+type HierarchyNode struct {
+	Value  string `json:"value" db:"node_value"`
+	Parent string `json:"parent,omitempty" db:"parent_value"`
+}
+
+// Substitution dictionary format (user-supplied fake values)
+type Dictionary struct {
+	Name    string   `json:"name" db:"dictionary_name"`
+	Entries []string `json:"entries"`
+}
+
+// PiiRule defines a PII pattern to detect in free text and its replacement policy
+type PiiRule struct {
+	Pattern     string `json:"pattern"`               // built-in pattern (rrn, phone, email, card, passport, ip) or name of custom pattern
+	Regex       string `json:"regex,omitempty"`       // custom pattern
+	Policy      string `json:"policy,omitempty"`      // mask (default), redact, remove
+	Replacement string `json:"replacement,omitempty"` // replacement for redact policy
 }
@@ -19,6 +19,7 @@ import (
 	// Util
 	"privacydam-go/v1/process/util/auth"
 	"privacydam-go/v1/process/util/db"
+	"privacydam-go/v1/process/util/did"
 )
 
 // func ProcessTestInEcho(ctx echo.Context) error {
@@ -261,6 +262,38 @@ func GetDeIdentificationOptions(ctx context.Context, tracking bool, id string) (
 	}
 }
 
+/*
+ * Get a export policy
+ * <IN> ctx (context.Context): context
+ * <IN> tracking (bool): tracking with AWS X-Ray
+ * <IN> id (string): API id by generated database
+ * <OUT> (model.ExportPolicy): export policy (default policy if not exists)
+ * <OUT> (error): error object (contain nil)
+ */
+func GetExportPolicy(ctx context.Context, tracking bool, id string) (model.ExportPolicy, error) {
+	var subCtx context.Context = ctx
+	var subSegment *xray.Segment
+	// [For debug] set subsegment
+	if tracking {
+		subCtx, subSegment = xray.BeginSubsegment(ctx, "Get export policy")
+		defer subSegment.Close(nil)
+	}
+
+	// Set default export policy
+	var policy model.ExportPolicy
+
+	// Get export policy
+	rawPolicy, err := db.In_getExportPolicy(subCtx, id)
+	if err != nil {
+		return policy, err
+	}
+	// Transform to structure
+	if rawPolicy != "" {
+		err = json.Unmarshal([]byte(rawPolicy), &policy)
+	}
+	return policy, err
+}
+
 /*
  * Authenticate access on server (on echo framework)
  * <IN> ctx (echo.Context): context
@@ -287,7 +320,75 @@ func AuthenticateAccessOnEcho(ctx echo.Context, tracking bool, server string) er
 }
 
 /*
- * Export data (process for export API)
+ * Decrypt data encrypted by format-preserving encryption (authorized access only)
+ * <IN> ctx (context.Context): context
+ * <IN> tracking (bool): tracking with AWS X-Ray
+ * <IN> server (string): OPA server host (contain protocal, host, port)
+ * <IN> token (string): access token
+ * <IN> options (model.AnoOption): de-identification options used for encryption
+ * <IN> values ([]string): a list of encrypted value
+ * <OUT> ([]string): a list of decrypted value
+ * <OUT> (error): error object (contain nil)
+ */
+func DecryptData(ctx context.Context, tracking bool, server string, token string, options model.AnoOption, values []string) ([]string, error) {
+	var subCtx context.Context = ctx
+	var subSegment *xray.Segment
+	// [For debug] set subsegment
+	if tracking {
+		subCtx, subSegment = xray.BeginSubsegment(ctx, "Decrypt data")
+		defer subSegment.Close(nil)
+	}
+
+	// Authenticate access token (using another OPA)
+	if err := auth.AuthenticateAccess(subCtx, tracking, server, token); err != nil {
+		return nil, err
+	}
+
+	// Build decrypting function
+	decrypt, err := did.BuildDecryptingFunc(options)
+	if err != nil {
+		return nil, err
+	}
+	// Decrypt
+	result := make([]string, len(values))
+	for i, value := range values {
+		if result[i], err = decrypt(value); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+/*
+ * Detokenize data (authorized access only)
+ * <IN> ctx (context.Context): context
+ * <IN> tracking (bool): tracking with AWS X-Ray
+ * <IN> server (string): OPA server host (contain protocal, host, port)
+ * <IN> accessToken (string): access token
+ * <IN> domain (string): token domain
+ * <IN> tokens ([]string): a list of token
+ * <OUT> ([]string): a list of original value
+ * <OUT> (error): error object (contain nil)
+ */
+func Detokenize(ctx context.Context, tracking bool, server string, accessToken string, domain string, tokens []string) ([]string, error) {
+	var subCtx context.Context = ctx
+	var subSegment *xray.Segment
+	// [For debug] set subsegment
+	if tracking {
+		subCtx, subSegment = xray.BeginSubsegment(ctx, "Detokenize data")
+		defer subSegment.Close(nil)
+	}
+
+	// Authenticate access token (using another OPA)
+	if err := auth.AuthenticateAccess(subCtx, tracking, server, accessToken); err != nil {
+		return nil, err
+	}
+	// Detokenize
+	return did.Detokenize(domain, tokens)
+}
+
+/*
+ * Export data (process for export API, default export policy)
  * <IN> ctx (context.Context): context
  * <IN> tracking (bool): tracking with AWS X-Ray
  * <IN> res (http.ResponseWriter): responseWriter object
@@ -300,13 +401,69 @@ func AuthenticateAccessOnEcho(ctx echo.Context, tracking bool, server string) er
  * <OUT> (error): error object (contain nil)
  */
 func ExportData(ctx context.Context, tracking bool, res http.ResponseWriter, apiName string, sourceId string, querySyntax string, params []interface{}, didOptions map[string]model.AnoParamOption) (model.Evaluation, error) {
+	return ExportDataWithPolicy(ctx, tracking, res, apiName, sourceId, querySyntax, params, didOptions, model.ExportPolicy{})
+}
+
+/*
+ * Export data with export policy of API (process for export API, the policy is loaded by GetExportPolicy)
+ * <IN> ctx (context.Context): context
+ * <IN> tracking (bool): tracking with AWS X-Ray
+ * <IN> res (http.ResponseWriter): responseWriter object
+ * <IN> apiName (string): api name
+ * <IN> sourceId (string): api source id by generated database
+ * <IN> querySyntax (string) syntax to query
+ * <IN> params ([]interface{}): parameters to query
+ * <IN> didOptions (map[string]model.AnoParamOption): de-identification options
+ * <IN> policy (model.ExportPolicy): export policy (strict mode, k-anonymity target and enforcement)
+ * <OUT> (model.Evaluation): k-anonymity evaluation result
+ * <OUT> (error): error object (contain nil)
+ */
+func ExportDataWithPolicy(ctx context.Context, tracking bool, res http.ResponseWriter, apiName string, sourceId string, querySyntax string, params []interface{}, didOptions map[string]model.AnoParamOption, policy model.ExportPolicy) (model.Evaluation, error) {
+	// Check api name
+	name := apiName
+	if apiName == "" {
+		name = "undefined_apiName"
+	}
+	// Processing
+	return db.Ex_exportData(ctx, tracking, res, name, sourceId, querySyntax, params, didOptions, policy)
+}
+
+/*
+ * Analyze re-identification risk (process for risk report API, the data is not released and no token is issued)
+ * <IN> ctx (context.Context): context
+ * <IN> tracking (bool): tracking with AWS X-Ray
+ * <IN> res (http.ResponseWriter): responseWriter object (write the report in JSON format, contain nil)
+ * <IN> apiName (string): api name
+ * <IN> sourceId (string): api source id by generated database
+ * <IN> querySyntax (string) syntax to query
+ * <IN> params ([]interface{}): parameters to query
+ * <IN> didOptions (map[string]model.AnoParamOption): de-identification options
+ * <IN> policy (model.ExportPolicy): export policy
+ * <IN> samplingFraction (float64): fraction of population in the data (default: 1)
+ * <IN> limit (int): maximum number of records at highest risk
+ * <OUT> (model.RiskReport): re-identification risk report
+ * <OUT> (error): error object (contain nil)
+ */
+func AnalyzeRisk(ctx context.Context, tracking bool, res http.ResponseWriter, apiName string, sourceId string, querySyntax string, params []interface{}, didOptions map[string]model.AnoParamOption, policy model.ExportPolicy, samplingFraction float64, limit int) (model.RiskReport, error) {
 	// Check api name
 	name := apiName
 	if apiName == "" {
 		name = "undefined_apiName"
 	}
 	// Processing
-	return db.Ex_exportData(ctx, tracking, res, name, sourceId, querySyntax, params, didOptions)
+	report, err := db.Ex_analyzeRisk(ctx, tracking, name, sourceId, querySyntax, params, didOptions, policy, samplingFraction, limit)
+	if err != nil || res == nil {
+		return report, err
+	}
+
+	// Write report in response body
+	rawReport, err := json.Marshal(report)
+	if err != nil {
+		return report, err
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(rawReport)
+	return report, nil
 }
 
 /*
@@ -0,0 +1,19 @@
+package db
+
+import (
+	"context"
+
+	// Util
+	"privacydam-go/v1/process/util/did"
+)
+
+// internalDictionaryStore loads substitution dictionaries from internal database (dictionary, dictionary_entry table)
+type internalDictionaryStore struct{}
+
+func (internalDictionaryStore) LoadDictionary(name string) ([]string, error) {
+	return In_getDictionary(context.Background(), name)
+}
+
+func init() {
+	did.SetDictionaryStore(internalDictionaryStore{})
+}
@@ -92,7 +92,25 @@ func Ex_changeData(ctx context.Context, tracking bool, sourceId string, querySyn
 	}
 }
 
-func Ex_exportData(ctx context.Context, tracking bool, res http.ResponseWriter, apiName string, sourceId string, querySyntax string, params []interface{}, didOptions map[string]model.AnoParamOption) (model.Evaluation, error) {
+func Ex_exportData(ctx context.Context, tracking bool, res http.ResponseWriter, apiName string, sourceId string, querySyntax string, params []interface{}, didOptions map[string]model.AnoParamOption, policy model.ExportPolicy) (model.Evaluation, error) {
+	return exportData(ctx, tracking, res, apiName, sourceId, querySyntax, params, didOptions, policy, nil)
+}
+
+func Ex_analyzeRisk(ctx context.Context, tracking bool, apiName string, sourceId string, querySyntax string, params []interface{}, didOptions map[string]model.AnoParamOption, policy model.ExportPolicy, samplingFraction float64, limit int) (model.RiskReport, error) {
+	evaluation, err := exportData(ctx, tracking, nil, apiName, sourceId, querySyntax, params, didOptions, policy, &riskOptions{samplingFraction: samplingFraction, limit: limit})
+	if err != nil || evaluation.Risk == nil {
+		return model.RiskReport{ApiName: apiName}, err
+	}
+	return *evaluation.Risk, nil
+}
+
+// riskOptions is options for risk analysis (the data is processed without being released)
+type riskOptions struct {
+	samplingFraction float64
+	limit            int
+}
+
+func exportData(ctx context.Context, tracking bool, res http.ResponseWriter, apiName string, sourceId string, querySyntax string, params []interface{}, didOptions map[string]model.AnoParamOption, policy model.ExportPolicy, risk *riskOptions) (model.Evaluation, error) {
 	// Set default evaluation structure
 	evaluation := model.Evaluation{}
 	// Get database object
@@ -108,6 +126,15 @@ func Ex_exportData(ctx context.Context, tracking bool, res http.ResponseWriter,
 		_, subSegment = xray.BeginSubsegment(ctx, "Process change")
 	}
 	/* Prepare part */
+	// Compute boundaries for quantile-based data range (pre-pass)
+	didOptions, err = prepareQuantileBoundaries(ctx, dbInfo, querySyntax, params, didOptions, risk != nil)
+	if err != nil {
+		if tracking {
+			subSegment.Close(nil)
+		}
+		return evaluation, err
+	}
+
 	// Get queue size from environment various (default: 10,000)
 	queueSize, err := strconv.ParseInt(os.Getenv("QUEUE_SIZE"), 10, 64)
 	if err != nil {
@@ -125,14 +152,16 @@ func Ex_exportData(ctx context.Context, tracking bool, res http.ResponseWriter,
 	nTransProc := uint64(routineCount)
 	nAnonyProc := uint64(routineCount)
 	// Create channel(data queue) for go-routine
-	iDataQueue := make(chan []interface{}, queueSize)
-	tDataQueue := make(chan []string, queueSize)
-	aDataQueue := make(chan []string, queueSize)
+	iDataQueue := make(chan queryRow, queueSize)
+	tDataQueue := make(chan exportRow, queueSize)
+	aDataQueue := make(chan exportRow, queueSize)
 	// Create channel(process queue) for go-routine
 	quitQuery := make(chan bool)
 	quitTrans := make(chan bool, nTransProc)
 	quitAnony := make(chan bool, nAnonyProc)
 	quitProce := make(chan model.Evaluation)
+	// Create report for de-identification failures
+	report := newDidErrorReport()
 	if tracking {
 		subSegment.Close(nil)
 	}
@@ -163,6 +192,23 @@ func Ex_exportData(ctx context.Context, tracking bool, res http.ResponseWriter,
 	if err != nil {
 		return evaluation, err
 	}
+	// Verify columns without de-identification options (before any row is streamed)
+	outputIndex, err := selectOutputColumns(columns, didOptions, policy)
+	if err != nil {
+		rows.Close()
+		if tracking {
+			subSegment.Close(nil)
+		}
+		return evaluation, err
+	}
+	header := make([]string, len(outputIndex))
+	for i, index := range outputIndex {
+		header[i] = columns[index]
+	}
+	// Select quasi-identifiers and sensitive columns to evaluate anonymity
+	evalFields, sensitiveFields := selectEvalFields(header, didOptions)
+	// Process fallback options for generalization of rows in small classes
+	withFallback := policy.SmallClass == "generalize" && risk == nil
 
 	// Extract query result
 	go executeExportQuery(subCtx, tracking, columnTypes, rows, iDataQueue, quitQuery)
@@ -172,10 +218,10 @@ func Ex_exportData(ctx context.Context, tracking bool, res http.ResponseWriter,
 	}
 	// Process de-identification
 	for i := uint64(0); i < nAnonyProc; i++ {
-		go processDeIdentification(subCtx, tracking, didOptions, columns, tDataQueue, aDataQueue, quitAnony)
+		go processDeIdentification(subCtx, tracking, didOptions, columns, outputIndex, withFallback, risk != nil, report, tDataQueue, aDataQueue, quitAnony)
 	}
 	// Write data
-	go writeExportedData(subCtx, tracking, res, apiName, columns, aDataQueue, quitProce)
+	go writeExportedData(subCtx, tracking, res, apiName, header, evalFields, sensitiveFields, policy, risk, report, aDataQueue, quitProce)
 
 	// Exit logic
 	completedTrans := uint64(0)
@@ -212,7 +258,13 @@ func Ex_exportData(ctx context.Context, tracking bool, res http.ResponseWriter,
 			if tracking {
 				subSegment.Close(nil)
 			}
-			return evaluation, nil
+			// Set de-identification failures (return error if the export was aborted)
+			err := report.apply(&evaluation)
+			// Refuse the export if the anonymity targets are not met (block mode)
+			if err == nil && risk == nil && policy.Enforcement == "block" && evaluation.Result != "true" {
+				err = errors.New("Anonymity targets are not met (" + strings.Join(evaluation.Failed, ", ") + ")")
+			}
+			return evaluation, err
 		}
 	}
 }
@@ -237,7 +289,285 @@ func Ex_exportData(ctx context.Context, tracking bool, res http.ResponseWriter,
 // 	return rows.ColumnTypes()
 // }
 
-func executeExportQuery(ctx context.Context, tracking bool, columnTypes []*sql.ColumnType, rows *sql.Rows, iDataQueue chan<- []interface{}, quitQuery chan<- bool) {
+// quantileTarget is the first quantile step (without boundaries) of a pipeline or fallback options
+// The values are collected after the steps before it (prefix) are applied
+type quantileTarget struct {
+	column   string
+	fallback bool
+	index    int
+	prefix   func(string, did.Row) (string, error)
+	values   []float64
+}
+
+/*
+ * Compute boundaries for quantile-based data range (pre-pass)
+ * <IN> ctx (context.Context): context
+ * <IN> dbInfo (model.ConnInfo): database object
+ * <IN> querySyntax (string): query syntax
+ * <IN> params ([]interface{}): query parameters
+ * <IN> didOptions (map[string]model.AnoParamOption): de-identification options
+ * <IN> readOnly (bool): process without writing to external stores (risk analysis)
+ * <OUT> (map[string]model.AnoParamOption): de-identification options with computed boundaries (copied)
+ * <OUT> (error): error object (contain nil)
+ */
+func prepareQuantileBoundaries(ctx context.Context, dbInfo model.ConnInfo, querySyntax string, params []interface{}, didOptions map[string]model.AnoParamOption, readOnly bool) (map[string]model.AnoParamOption, error) {
+	prepared := didOptions
+	// A pass computes the first quantile step of each pipeline (repeat for the following quantile steps)
+	for {
+		targets, err := findQuantileTargets(prepared)
+		if err != nil {
+			return didOptions, err
+		} else if len(targets) == 0 {
+			return prepared, nil
+		}
+		if err := collectQuantileValues(ctx, dbInfo, querySyntax, params, targets, readOnly); err != nil {
+			return didOptions, err
+		}
+		if prepared, err = applyQuantileBoundaries(prepared, targets); err != nil {
+			return didOptions, err
+		}
+	}
+}
+
+func findQuantileTargets(didOptions map[string]model.AnoParamOption) ([]*quantileTarget, error) {
+	passAsIs := func(inString string, row did.Row) (string, error) {
+		return inString, nil
+	}
+
+	targets := []*quantileTarget{}
+	for key, option := range didOptions {
+		for n, steps := range [][]model.AnoMethodOption{did.Steps(option), option.Fallback} {
+			for i, step := range steps {
+				if !isQuantileStep(step) {
+					continue
+				}
+				// Build the steps before the quantile step
+				prefix := passAsIs
+				if i > 0 {
+					fn, err := did.BuildRowFunc(model.AnoParamOption{Pipeline: steps[:i], KeyColumn: option.KeyColumn})
+					if err != nil {
+						return nil, errors.New("Invalid de-identification options (" + key + "): " + err.Error())
+					}
+					prefix = fn
+				}
+				targets = append(targets, &quantileTarget{column: key, fallback: n == 1, index: i, prefix: prefix})
+				break
+			}
+		}
+	}
+	return targets, nil
+}
+
+func collectQuantileValues(ctx context.Context, dbInfo model.ConnInfo, querySyntax string, params []interface{}, targets []*quantileTarget, readOnly bool) error {
+	// Execute query
+	var rows *sql.Rows
+	var err error
+	if dbInfo.Tracking {
+		rows, err = dbInfo.Instance.QueryContext(ctx, querySyntax, params...)
+	} else {
+		rows, err = dbInfo.Instance.Query(querySyntax, params...)
+	}
+	// Catch error
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Extract column types and column names
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	index := make(map[string]int, len(columns))
+	for i, column := range columns {
+		index[column] = i
+	}
+
+	// Collect values of target columns (processed by the steps before the quantile step with row context)
+	layout := did.NewRowLayout(columns)
+	for seq := int64(0); rows.Next(); seq++ {
+		allocated := allocateMemoryByScanType(columnTypes)
+		if err := rows.Scan(allocated...); err != nil {
+			return err
+		}
+		converted := make([]string, len(columns))
+		for i, column := range allocated {
+			if columnTypes[i].ScanType() == nil {
+				converted[i] = transformToString("string", column)
+			} else {
+				converted[i] = transformToString(columnTypes[i].ScanType().String(), column)
+			}
+		}
+		row := layout.Row(seq, converted)
+		if readOnly {
+			row = row.ReadOnly()
+		}
+		for _, target := range targets {
+			i, exists := index[target.column]
+			if !exists {
+				continue
+			}
+			output, err := target.prefix(converted[i], row.WithColumn(target.column))
+			if err != nil {
+				continue
+			}
+			if value, err := strconv.ParseFloat(output, 64); err == nil {
+				target.values = append(target.values, value)
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func applyQuantileBoundaries(didOptions map[string]model.AnoParamOption, targets []*quantileTarget) (map[string]model.AnoParamOption, error) {
+	// Copy options and set computed boundaries
+	prepared := make(map[string]model.AnoParamOption, len(didOptions))
+	for key, option := range didOptions {
+		prepared[key] = option
+	}
+	for _, target := range targets {
+		option := prepared[target.column]
+		var steps []model.AnoMethodOption
+		if target.fallback {
+			steps = append(steps, option.Fallback...)
+		} else {
+			steps = append(steps, did.Steps(option)...)
+		}
+		step := steps[target.index]
+		binNum, err := strconv.ParseInt(step.Options.Bin, 10, 0)
+		if err != nil || binNum < 1 {
+			return didOptions, errors.New("Invalid bin parameter for quantile data range (" + target.column + ")")
+		}
+		steps[target.index].Options.Boundaries, steps[target.index].Options.Labels = did.ComputeQuantileBoundaries(target.values, int(binNum), step.Options.Labels)
+		if target.fallback {
+			option.Fallback = steps
+		} else {
+			option.Pipeline = steps
+		}
+		prepared[target.column] = option
+	}
+	return prepared, nil
+}
+
+func isQuantileStep(step model.AnoMethodOption) bool {
+	return step.Method == "data_range" && step.Options.Algorithm == "quantile" && len(step.Options.Boundaries) == 0
+}
+
+/*
+ * Select columns to export by strict mode (process for columns without de-identification options)
+ * <IN> columns ([]string): a list of column name (query result)
+ * <IN> didOptions (map[string]model.AnoParamOption): de-identification options
+ * <IN> policy (model.ExportPolicy): export policy (default: DID_STRICT_MODE environment various)
+ * <OUT> ([]int): a list of column index to export
+ * <OUT> (error): error object (contain nil)
+ */
+func selectOutputColumns(columns []string, didOptions map[string]model.AnoParamOption, policy model.ExportPolicy) ([]int, error) {
+	// Set mode
+	mode := policy.UnconfiguredColumn
+	if mode == "" {
+		mode = os.Getenv("DID_STRICT_MODE")
+	}
+
+	// Find unconfigured columns
+	outputIndex := make([]int, 0, len(columns))
+	unmatched := make([]string, 0)
+	for i, column := range columns {
+		if _, exists := didOptions[column]; exists {
+			outputIndex = append(outputIndex, i)
+		} else {
+			unmatched = append(unmatched, column)
+			if mode != "drop" {
+				outputIndex = append(outputIndex, i)
+			}
+		}
+	}
+
+	switch mode {
+	case "", "pass":
+		return outputIndex, nil
+	case "drop":
+		if len(unmatched) > 0 {
+			log.Println("[NOTICE] Drop columns without de-identification options: " + strings.Join(unmatched, ", "))
+		}
+		return outputIndex, nil
+	case "reject":
+		if len(unmatched) > 0 {
+			return nil, errors.New("Columns without de-identification options (strict mode): " + strings.Join(unmatched, ", "))
+		}
+		return outputIndex, nil
+	default:
+		return nil, errors.New("Invalid strict mode (" + mode + ")")
+	}
+}
+
+/*
+ * Select fields to evaluate anonymity by column role (identifier and insensitive columns are excluded)
+ * <IN> header ([]string): a list of column name to export
+ * <IN> didOptions (map[string]model.AnoParamOption): de-identification options
+ * <OUT> ([]bool): quasi-identifier or not for each column (columns without role are quasi-identifiers)
+ * <OUT> ([]bool): sensitive or not for each column
+ */
+func selectEvalFields(header []string, didOptions map[string]model.AnoParamOption) ([]bool, []bool) {
+	evalFields := make([]bool, len(header))
+	sensitiveFields := make([]bool, len(header))
+	for i, column := range header {
+		switch didOptions[column].Role {
+		case "", "quasi_identifier":
+			evalFields[i] = true
+		case "sensitive":
+			sensitiveFields[i] = true
+		}
+	}
+	return evalFields, sensitiveFields
+}
+
+// evaluateAnonymity creates evaluation result (the result is true if all targets are met)
+func evaluateAnonymity(evaluater *kAno.AnoTester, kValue int) model.Evaluation {
+	evaluation := model.Evaluation{Target: int64(kValue)}
+
+	kResult, kActual := evaluater.Eval()
+	evaluation.Value = int64(kActual)
+	if !kResult {
+		evaluation.Failed = append(evaluation.Failed, "k")
+	}
+	lResult, lActual := evaluater.EvalDistinctL()
+	evaluation.LDiversity = int64(lActual)
+	if !lResult {
+		evaluation.Failed = append(evaluation.Failed, "l")
+	}
+	entropyResult, entropyActual := evaluater.EvalEntropyL()
+	evaluation.EntropyLDiversity = entropyActual
+	if !entropyResult {
+		evaluation.Failed = append(evaluation.Failed, "entropyL")
+	}
+	tResult, tActual := evaluater.EvalTCloseness()
+	evaluation.TCloseness = tActual
+	if !tResult {
+		evaluation.Failed = append(evaluation.Failed, "t")
+	}
+
+	evaluation.Result = strconv.FormatBool(len(evaluation.Failed) == 0)
+	return evaluation
+}
+
+// queryRow is a scanned row of query result with its sequence number (order of query result, 0-based)
+type queryRow struct {
+	seq    int64
+	values []interface{}
+}
+
+// exportRow is a transformed (or de-identified) row with the sequence number of query result
+type exportRow struct {
+	seq      int64
+	values   []string
+	fallback []string // values generalized by fallback options (for rows in small classes, contain nil)
+}
+
+func executeExportQuery(ctx context.Context, tracking bool, columnTypes []*sql.ColumnType, rows *sql.Rows, iDataQueue chan<- queryRow, quitQuery chan<- bool) {
 	// [For debug] Set the subsegment
 	if tracking {
 		_, subSegment := xray.BeginSubsegment(ctx, "Export data")
@@ -245,12 +575,12 @@ func executeExportQuery(ctx context.Context, tracking bool, columnTypes []*sql.C
 	}
 	defer rows.Close()
 
-	// Extract query result
-	for rows.Next() {
+	// Extract query result (numbering rows in order of query result)
+	for seq := int64(0); rows.Next(); seq++ {
 		allocated := allocateMemoryByScanType(columnTypes)
 		// Scan and store
 		rows.Scan(allocated...)
-		iDataQueue <- allocated
+		iDataQueue <- queryRow{seq: seq, values: allocated}
 	}
 	// Catch error
 	if err := rows.Err(); err != nil {
@@ -261,7 +591,7 @@ func executeExportQuery(ctx context.Context, tracking bool, columnTypes []*sql.C
 	}
 }
 
-func transformQueryResult(ctx context.Context, tracking bool, columnTypes []*sql.ColumnType, iDataQueue <-chan []interface{}, tDataQueue chan<- []string, procQueue chan<- bool) {
+func transformQueryResult(ctx context.Context, tracking bool, columnTypes []*sql.ColumnType, iDataQueue <-chan queryRow, tDataQueue chan<- exportRow, procQueue chan<- bool) {
 	// [For debug] Set the subsegment
 	if tracking {
 		_, subSegment := xray.BeginSubsegment(ctx, "Process transformation")
@@ -270,64 +600,135 @@ func transformQueryResult(ctx context.Context, tracking bool, columnTypes []*sql
 
 	for v, ok := <-iDataQueue; ok; v, ok = <-iDataQueue {
 		converted := make([]string, len(columnTypes))
-		for i, column := range v {
+		for i, column := range v.values {
 			if columnTypes[i].ScanType() == nil {
 				converted[i] = transformToString("string", column)
 			} else {
 				converted[i] = transformToString(columnTypes[i].ScanType().String(), column)
 			}
 		}
-		tDataQueue <- converted
+		tDataQueue <- exportRow{seq: v.seq, values: converted}
 	}
 	procQueue <- true
 }
 
-func processDeIdentification(ctx context.Context, tracking bool, options map[string]model.AnoParamOption, columns []string, tDataQueue <-chan []string, aDataQueue chan<- []string, quitAnony chan<- bool) {
+func processDeIdentification(ctx context.Context, tracking bool, options map[string]model.AnoParamOption, columns []string, outputIndex []int, withFallback bool, readOnly bool, report *didErrorReport, tDataQueue <-chan exportRow, aDataQueue chan<- exportRow, quitAnony chan<- bool) {
 	// [For debug] Set the subsegment
 	if tracking {
 		_, subSegment := xray.BeginSubsegment(ctx, "Process de-identification")
 		defer subSegment.Close(nil)
 	}
 
-	// build processing functions
-	funcList := [](func(string) string){}
-	passAsIs := func(inString string) string {
-		return inString
+	// build processing functions (for output columns)
+	funcList := [](func(string, did.Row) (string, error)){}
+	policyList := []model.AnoParamOption{}
+	passAsIs := func(inString string, row did.Row) (string, error) {
+		return inString, nil
 	}
-	dropAll := func(inString string) string {
-		return ""
+	dropAll := func(inString string, row did.Row) (string, error) {
+		return "", nil
 	}
 
-	for _, key := range columns {
+	for _, index := range outputIndex {
+		key := columns[index]
 		if option, exists := options[key]; exists == true {
-			switch option.Method {
-			case "encryption":
-				funcList = append(funcList, did.BuildEncryptingFunc(option.Options))
-			case "rounding":
-				funcList = append(funcList, did.BuildRoundingFunc(option.Options))
-			case "data_range":
-				funcList = append(funcList, did.BuildRangingFunc(option.Options))
-			case "blank_impute":
-				funcList = append(funcList, did.BuildMaskingFunc(option.Options))
-			case "pii_reduction":
-				funcList = append(funcList, did.BuildMaskingFunc(option.Options))
-			case "non":
-				funcList = append(funcList, passAsIs)
-			default:
-				funcList = append(funcList, dropAll)
+			// Build a function (drop all and stop the export if the method or options are invalid)
+			fn, err := did.BuildRowFunc(option)
+			if err != nil {
+				log.Println("[WARNING] Invalid de-identification options (" + key + "): " + err.Error())
+				report.fail(errors.New("Invalid de-identification options (" + key + "): " + err.Error()))
+				fn = dropAll
 			}
+			funcList = append(funcList, fn)
+			policyList = append(policyList, option)
 		} else {
 			funcList = append(funcList, passAsIs)
+			policyList = append(policyList, model.AnoParamOption{})
+		}
+	}
+
+	// build fallback functions (coarser generalization for rows in small classes, nil if not exists)
+	fallbackList := make([](func(string, did.Row) (string, error)), len(outputIndex))
+	for i, index := range outputIndex {
+		key := columns[index]
+		if option := options[key]; withFallback && len(option.Fallback) > 0 {
+			fn, err := did.BuildRowFunc(model.AnoParamOption{Pipeline: option.Fallback, KeyColumn: option.KeyColumn})
+			if err != nil {
+				log.Println("[WARNING] Invalid fallback options (" + key + "): " + err.Error())
+				report.fail(errors.New("Invalid fallback options (" + key + "): " + err.Error()))
+				fn = dropAll
+			}
+			fallbackList[i] = fn
+		}
+	}
+
+	// Row context for the methods that refer to the other columns
+	layout := did.NewRowLayout(columns)
+	// Count errors handled inside a method (e.g. nested options of json_fields)
+	errorHandlers := make([]func(error), len(outputIndex))
+	for i, index := range outputIndex {
+		column := columns[index]
+		errorHandlers[i] = func(err error) {
+			report.add(column)
 		}
 	}
 
 	cnt := 0
 	for v, ok := <-tDataQueue; ok; v, ok = <-tDataQueue {
-		output := []string{}
-		for i, value := range v {
-			output = append(output, funcList[i](value))
+		// Skip processing after abort (drain queue)
+		if report.aborted() {
+			continue
+		}
+		output := make([]string, len(outputIndex))
+		row := layout.Row(v.seq, v.values)
+		if readOnly {
+			row = row.ReadOnly()
+		}
+		suppressed := false
+		for i, index := range outputIndex {
+			result, err := funcList[i](v.values[index], row.WithColumn(columns[index]).WithErrorHandler(errorHandlers[i]))
+			if err != nil {
+				// Process by error policy of column (suppress or abort of nested options is applied to the row)
+				report.add(columns[index])
+				policy := policyList[i].OnError
+				if policyErr, ok := err.(*did.PolicyError); ok {
+					policy = policyErr.Policy
+				}
+				switch policy {
+				case "suppress":
+					suppressed = true
+				case "replace":
+					result = policyList[i].Replacement
+				case "abort":
+					report.abort(columns[index], err)
+				default:
+					result = ""
+				}
+			}
+			output[i] = result
+		}
+		if suppressed {
+			report.suppress()
+			continue
 		}
-		aDataQueue <- output
+		// Generalize by fallback options (the values of columns without fallback options are not changed)
+		var fallback []string
+		if withFallback {
+			fallback = make([]string, len(outputIndex))
+			for i, index := range outputIndex {
+				fallback[i] = output[i]
+				if fallbackList[i] == nil {
+					continue
+				}
+				result, err := fallbackList[i](v.values[index], row.WithColumn(columns[index]).WithErrorHandler(errorHandlers[i]))
+				if err != nil {
+					report.add(columns[index])
+					result = ""
+				}
+				fallback[i] = result
+			}
+		}
+		aDataQueue <- exportRow{seq: v.seq, values: output, fallback: fallback}
 		cnt++
 	}
 
@@ -335,40 +736,121 @@ func processDeIdentification(ctx context.Context, tracking bool, options map[str
 	quitAnony <- true
 }
 
-func writeExportedData(ctx context.Context, tracking bool, res http.ResponseWriter, name string, header []string, aDataQueue <-chan []string, quitProce chan<- model.Evaluation) {
+func writeExportedData(ctx context.Context, tracking bool, res http.ResponseWriter, name string, header []string, evalFields []bool, sensitiveFields []bool, policy model.ExportPolicy, risk *riskOptions, report *didErrorReport, aDataQueue <-chan exportRow, quitProce chan<- model.Evaluation) {
 	// Set the subsegment
 	if tracking {
 		_, subSegment := xray.BeginSubsegment(ctx, "Write data in response body")
 		defer subSegment.Close(nil)
 	}
 
-	// Set a file name
-	filename := name + "_export.csv"
-	// Set response header
-	res.Header().Set("Connection", "Keep-Alive")
-	res.Header().Set("Transfer-Encoding", "chunked")
-	res.Header().Set("X-Content-Type-Options", "nosniff")
-	// Set stream file in response header
-	res.Header().Set("Content-Disposition", "attachment;filename="+filename)
-	res.Header().Set("Content-Type", "application/octet-stream")
+	// Set k-anonymity target (default: 2)
+	kValue := policy.KValue
+	if kValue == 0 {
+		kValue = 2
+	}
+	// Block mode and small class processing hold all rows until k-anonymity is evaluated (nothing is written for risk analysis)
+	smallClass := policy.SmallClass
+	if smallClass == "keep" || risk != nil {
+		smallClass = ""
+	}
+	holding := risk == nil && (policy.Enforcement == "block" || smallClass != "")
+	streaming := risk == nil && !holding
+	spool := newRowSpool()
+	defer spool.close()
 
 	// Create k-anonymity tester
-	evaluater := new(kAno.AnoTester)
-	evaluater.New(len(header), 2)
+	evaluater := newAnoTester(header, evalFields, sensitiveFields, policy, kValue)
 
 	// Transform header data to csv format
 	lineCount := int64(0)
 	buffer := transformToCsvFormat(header)
-	res.Write(buffer.Bytes())
+	if streaming {
+		setExportHeader(res, name)
+		res.Write(buffer.Bytes())
+	}
 	// Export process
 	for row, ok := <-aDataQueue; ok; row, ok = <-aDataQueue {
-		// Add data to evaluate k-anonymity
-		evaluater.AddStrings(row)
+		// Stop writing after abort (drain queue)
+		if report.aborted() {
+			continue
+		}
+		// Add data to evaluate k-anonymity (with the sequence number of query result)
+		evaluater.AddRow(row.seq, row.values)
+		lineCount++
+		if holding {
+			// Fallback values are held after the values of row
+			if err := spool.add(append(row.values, row.fallback...)); err != nil {
+				report.fail(err)
+			}
+			continue
+		} else if !streaming {
+			continue
+		}
 		// Transform exported data and write data
 		buffer.Reset()
-		buffer = transformToCsvFormat(row)
+		buffer = transformToCsvFormat(row.values)
 		res.Write(buffer.Bytes())
-		lineCount++
+	}
+
+	// Evaluate k-anonymity (and l-diversity, t-closeness for sensitive columns)
+	evaluation := evaluateAnonymity(evaluater, kValue)
+	// Create risk report
+	if risk != nil {
+		riskReport := evaluater.RiskReport(risk.samplingFraction, risk.limit)
+		riskReport.ApiName = name
+		evaluation.Risk = &riskReport
+	}
+
+	if holding && !report.aborted() {
+		// Suppress or generalize rows in small classes (evaluate released rows in another pass)
+		filter, err := smallClassFilter(spool, evaluater, smallClass, kValue, header, evalFields, sensitiveFields, policy)
+		if err != nil {
+			report.fail(err)
+		}
+		if filter != nil {
+			released := newAnoTester(header, evalFields, sensitiveFields, policy, kValue)
+			var suppressed, generalized int64
+			err := spool.each(func(index int, row []string) error {
+				output, ok, isGeneralized := filter(index, row)
+				if !ok {
+					suppressed++
+				} else {
+					if isGeneralized {
+						generalized++
+					}
+					released.AddStrings(output)
+				}
+				return nil
+			})
+			if err != nil {
+				report.fail(err)
+			}
+			evaluation = evaluateAnonymity(released, kValue)
+			evaluation.SmallClassSuppressedRows = suppressed
+			evaluation.SmallClassGeneralizedRows = generalized
+		} else {
+			filter = func(index int, row []string) ([]string, bool, bool) {
+				return row, true, false
+			}
+		}
+
+		// Release held rows (only if the target is met in block mode)
+		if !report.aborted() && (policy.Enforcement != "block" || evaluation.Result == "true") {
+			setExportHeader(res, name)
+			buffer = transformToCsvFormat(header)
+			res.Write(buffer.Bytes())
+			err := spool.each(func(index int, row []string) error {
+				if output, ok, _ := filter(index, row); ok {
+					buffer.Reset()
+					buffer = transformToCsvFormat(output)
+					res.Write(buffer.Bytes())
+				}
+				return nil
+			})
+			if err != nil {
+				report.fail(err)
+			}
+		}
 	}
 	// Debug logging status
 	buffer.Reset()
@@ -376,18 +858,100 @@ func writeExportedData(ctx context.Context, tracking bool, res http.ResponseWrit
 	buffer.WriteString(strconv.FormatInt(lineCount, 10))
 	buffer.WriteString("lines.")
 
-	// Evaluate k-anonymity
-	evalResult, actValue := evaluater.Eval()
-	evaluation := model.Evaluation{
-		Result: strconv.FormatBool(evalResult),
-		Value:  int64(actValue),
-	}
-
 	// Exit
 	quitProce <- evaluation
 	evaluater = nil
 }
 
+// newAnoTester creates k-anonymity tester by export policy
+func newAnoTester(header []string, evalFields []bool, sensitiveFields []bool, policy model.ExportPolicy, kValue int) *kAno.AnoTester {
+	evaluater := new(kAno.AnoTester)
+	evaluater.New(len(header), kValue)
+	evaluater.SetEvalFields(evalFields)
+	evaluater.SetSensitiveFields(sensitiveFields)
+	evaluater.SetDiversityTargets(policy.LValue, policy.EntropyLValue, policy.TValue)
+	return evaluater
+}
+
+/*
+ * Create a filter for rows in equivalence classes smaller than k
+ * <IN> spool (*rowSpool): held rows (fallback values are held after the values of row)
+ * <IN> evaluater (*kAno.AnoTester): k-anonymity tester (evaluated all rows)
+ * <IN> mode (string): process for rows in small classes (suppress, generalize)
+ * <IN> kValue (int): k-anonymity target
+ * <IN> header ([]string): a list of column name to export
+ * <IN> evalFields ([]bool): quasi-identifier or not for each column
+ * <IN> sensitiveFields ([]bool): sensitive or not for each column
+ * <IN> policy (model.ExportPolicy): export policy
+ * <OUT> (func(int, []string) ([]string, bool, bool)): filter (return processed row, released or not, generalized or not), nil if rows are kept
+ * <OUT> (error): error object (contain nil)
+ */
+func smallClassFilter(spool *rowSpool, evaluater *kAno.AnoTester, mode string, kValue int, header []string, evalFields []bool, sensitiveFields []bool, policy model.ExportPolicy) (func(int, []string) ([]string, bool, bool), error) {
+	// Split held row into values and fallback values
+	width := len(header)
+	split := func(row []string) ([]string, []string) {
+		if len(row) > width {
+			return row[:width], row[width:]
+		}
+		return row, nil
+	}
+
+	switch mode {
+	case "suppress":
+		return func(index int, row []string) ([]string, bool, bool) {
+			values, _ := split(row)
+			if evaluater.RowClassSize(index) < kValue {
+				return nil, false, false
+			}
+			return values, true, false
+		}, nil
+	case "generalize":
+		// Evaluate classes after rows in small classes are generalized by fallback options
+		generalized := newAnoTester(header, evalFields, sensitiveFields, policy, kValue)
+		err := spool.each(func(index int, row []string) error {
+			values, fallback := split(row)
+			if evaluater.RowClassSize(index) < kValue && fallback != nil {
+				values = fallback
+			}
+			generalized.AddStrings(values)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Rows that are still in small classes after generalization are suppressed
+		return func(index int, row []string) ([]string, bool, bool) {
+			values, fallback := split(row)
+			if evaluater.RowClassSize(index) >= kValue {
+				return values, true, false
+			} else if fallback == nil || generalized.RowClassSize(index) < kValue {
+				return nil, false, false
+			}
+			for i := range values {
+				if values[i] != fallback[i] {
+					return fallback, true, true
+				}
+			}
+			return fallback, true, false
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// setExportHeader sets response header to stream a csv file
+func setExportHeader(res http.ResponseWriter, name string) {
+	// Set a file name
+	filename := name + "_export.csv"
+	// Set response header
+	res.Header().Set("Connection", "Keep-Alive")
+	res.Header().Set("Transfer-Encoding", "chunked")
+	res.Header().Set("X-Content-Type-Options", "nosniff")
+	// Set stream file in response header
+	res.Header().Set("Content-Disposition", "attachment;filename="+filename)
+	res.Header().Set("Content-Type", "application/octet-stream")
+}
+
 func allocateMemoryByScanType(columns []*sql.ColumnType) []interface{} {
 	allocated := make([]interface{}, len(columns))
 	for i, column := range columns {
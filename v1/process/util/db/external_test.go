@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	// Model
+	"privacydam-go/v1/core/model"
+	// Util
+	"privacydam-go/v1/process/util/did"
+)
+
+// collectTestValues collects values of a column like the pre-pass (without database)
+func collectTestValues(t *testing.T, targets []*quantileTarget, column string, values []string) {
+	layout := did.NewRowLayout([]string{column})
+	for seq, value := range values {
+		row := layout.Row(int64(seq), []string{value})
+		for _, target := range targets {
+			output, err := target.prefix(value, row)
+			if err != nil {
+				t.Fatal(err)
+			}
+			target.values = append(target.values, parseTestFloat(t, output))
+		}
+	}
+}
+
+func parseTestFloat(t *testing.T, s string) float64 {
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func TestQuantilePrefix(t *testing.T) {
+	quantile := model.AnoMethodOption{Method: "data_range", Options: model.AnoOption{Algorithm: "quantile", Bin: "2"}}
+	options := map[string]model.AnoParamOption{
+		"age": {
+			// Boundaries are computed from the rounded values
+			Pipeline: []model.AnoMethodOption{
+				{Method: "rounding", Options: model.AnoOption{Algorithm: "round", Position: -1}},
+				quantile,
+			},
+			// Boundaries of fallback options are computed from the original values
+			Fallback: []model.AnoMethodOption{quantile},
+		},
+	}
+	values := []string{"11", "14", "16", "19", "31", "34", "36", "39"}
+
+	targets, err := findQuantileTargets(options)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(targets) != 2 {
+		t.Fatalf("findQuantileTargets() found %d targets, want 2", len(targets))
+	}
+	collectTestValues(t, targets, "age", values)
+	prepared, err := applyQuantileBoundaries(options, targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if boundaries := prepared["age"].Pipeline[1].Options.Boundaries; !reflect.DeepEqual(boundaries, []string{"10", "30"}) {
+		t.Errorf("boundaries of pipeline = %v, want [10 30]", boundaries)
+	}
+	if boundaries := prepared["age"].Fallback[0].Options.Boundaries; !reflect.DeepEqual(boundaries, []string{"11", "31"}) {
+		t.Errorf("boundaries of fallback = %v, want [11 31]", boundaries)
+	}
+	// Options of caller are not changed
+	if len(options["age"].Pipeline[1].Options.Boundaries) != 0 || len(options["age"].Fallback[0].Options.Boundaries) != 0 {
+		t.Error("options of caller are changed")
+	}
+	// All quantile steps are computed
+	if targets, _ := findQuantileTargets(prepared); len(targets) != 0 {
+		t.Errorf("findQuantileTargets() found %d targets after computation", len(targets))
+	}
+}
+
+// runDeIdentification processes rows by processDeIdentification (all columns are exported)
+func runDeIdentification(options map[string]model.AnoParamOption, columns []string, rows [][]string, report *didErrorReport) [][]string {
+	outputIndex := make([]int, len(columns))
+	for i := range columns {
+		outputIndex[i] = i
+	}
+	tDataQueue := make(chan exportRow, len(rows))
+	aDataQueue := make(chan exportRow, len(rows))
+	quitAnony := make(chan bool, 1)
+	for i, row := range rows {
+		tDataQueue <- exportRow{seq: int64(i), values: row}
+	}
+	close(tDataQueue)
+	processDeIdentification(context.Background(), false, options, columns, outputIndex, false, false, report, tDataQueue, aDataQueue, quitAnony)
+	close(aDataQueue)
+
+	output := [][]string{}
+	for row := range aDataQueue {
+		output = append(output, row.values)
+	}
+	return output
+}
+
+func TestNestedErrorPolicy(t *testing.T) {
+	nested := func(onError string) map[string]model.AnoParamOption {
+		field := model.AnoParamOption{Method: "rounding", Options: model.AnoOption{Algorithm: "round"}, OnError: onError}
+		return map[string]model.AnoParamOption{
+			"profile": {Method: "json_fields", Options: model.AnoOption{Fields: map[string]model.AnoParamOption{"$.age": field}}},
+		}
+	}
+	columns := []string{"id", "profile"}
+	rows := [][]string{{"1", `{"age":"31"}`}, {"2", `{"age":"unknown"}`}}
+
+	// Nested suppress removes the row (the policy of column is null)
+	report := newDidErrorReport()
+	if output := runDeIdentification(nested("suppress"), columns, rows, report); len(output) != 1 || output[0][0] != "1" {
+		t.Errorf("suppress: output = %v, want the first row", output)
+	}
+	evaluation := model.Evaluation{}
+	if err := report.apply(&evaluation); err != nil || evaluation.ErrorSuppressedRows != 1 || evaluation.ErrorCount != 1 {
+		t.Errorf("suppress: evaluation = %+v, %v", evaluation, err)
+	}
+
+	// Nested abort stops the export
+	report = newDidErrorReport()
+	runDeIdentification(nested("abort"), columns, rows, report)
+	if err := report.apply(&model.Evaluation{}); err == nil {
+		t.Error("abort: export is not aborted")
+	}
+}
+
+// runWriter writes rows by writeExportedData and returns the response body and evaluation
+func runWriter(policy model.ExportPolicy, header []string, evalFields []bool, rows []exportRow) (string, model.Evaluation, error) {
+	res := httptest.NewRecorder()
+	report := newDidErrorReport()
+	aDataQueue := make(chan exportRow, len(rows))
+	quitProce := make(chan model.Evaluation)
+	for _, row := range rows {
+		aDataQueue <- row
+	}
+	close(aDataQueue)
+	go writeExportedData(context.Background(), false, res, "test", header, evalFields, make([]bool, len(header)), policy, nil, report, aDataQueue, quitProce)
+	evaluation := <-quitProce
+	err := report.apply(&evaluation)
+	return res.Body.String(), evaluation, err
+}
+
+func TestSmallClass(t *testing.T) {
+	// Spill held rows to temporary file
+	t.Setenv("EXPORT_MEMORY_ROWS", "2")
+	header := []string{"age", "zip", "note"}
+	evalFields := []bool{true, true, false}
+	rows := []exportRow{
+		{seq: 0, values: []string{"20 ~ 30", "123", "a, b"}, fallback: []string{"20 ~ 40", "123", "a, b"}},
+		{seq: 1, values: []string{"20 ~ 30", "123", "c"}, fallback: []string{"20 ~ 40", "123", "c"}},
+		// Rows in small classes are released by fallback options if the generalized class is large enough
+		{seq: 2, values: []string{"30 ~ 40", "123", "d"}, fallback: []string{"20 ~ 40", "123", "d"}},
+		{seq: 3, values: []string{"20 ~ 25", "123", "e"}, fallback: []string{"20 ~ 40", "123", "e"}},
+		// Still in a small class after generalization
+		{seq: 4, values: []string{"50 ~ 60", "999", "f"}, fallback: []string{"40 ~ 60", "999", "f"}},
+	}
+
+	body, evaluation, err := runWriter(model.ExportPolicy{KValue: 2, SmallClass: "generalize"}, header, evalFields, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "age,zip,note\r\n" +
+		"20 ~ 30,123,\"a, b\"\r\n" +
+		"20 ~ 30,123,c\r\n" +
+		"20 ~ 40,123,d\r\n" +
+		"20 ~ 40,123,e\r\n"
+	if body != want {
+		t.Errorf("generalize: body = %q, want %q", body, want)
+	}
+	if evaluation.SmallClassGeneralizedRows != 2 || evaluation.SmallClassSuppressedRows != 1 || evaluation.Result != "true" || evaluation.Value != 2 {
+		t.Errorf("generalize: evaluation = %+v", evaluation)
+	}
+
+	// Suppress rows in small classes (rows without fallback values)
+	for i := range rows {
+		rows[i].fallback = nil
+	}
+	body, evaluation, err = runWriter(model.ExportPolicy{KValue: 2, SmallClass: "suppress"}, header, evalFields, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "age,zip,note\r\n" +
+		"20 ~ 30,123,\"a, b\"\r\n" +
+		"20 ~ 30,123,c\r\n"
+	if body != want {
+		t.Errorf("suppress: body = %q, want %q", body, want)
+	}
+	if evaluation.SmallClassGeneralizedRows != 0 || evaluation.SmallClassSuppressedRows != 3 || evaluation.Result != "true" {
+		t.Errorf("suppress: evaluation = %+v", evaluation)
+	}
+
+	// Keep rows in small classes (block mode refuses the export)
+	body, evaluation, err = runWriter(model.ExportPolicy{KValue: 2, Enforcement: "block"}, header, evalFields, rows)
+	if body != "" || evaluation.Result != "false" || evaluation.SmallClassSuppressedRows != 0 {
+		t.Errorf("block: body = %q, evaluation = %+v, %v", body, evaluation, err)
+	}
+}
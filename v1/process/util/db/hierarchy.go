@@ -0,0 +1,19 @@
+package db
+
+import (
+	"context"
+
+	// Util
+	"privacydam-go/v1/process/util/did"
+)
+
+// internalHierarchyStore loads generalization hierarchies from internal database (hierarchy, hierarchy_node table)
+type internalHierarchyStore struct{}
+
+func (internalHierarchyStore) LoadHierarchy(name string) (map[string]string, error) {
+	return In_getHierarchy(context.Background(), name)
+}
+
+func init() {
+	did.SetHierarchyStore(internalHierarchyStore{})
+}
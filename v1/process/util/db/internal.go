@@ -98,3 +98,235 @@ func In_getDeIdentificationOptions(ctx context.Context, id string) (string, erro
 	// Return
 	return options, rows.Err()
 }
+
+func In_getDeIdentificationKey(ctx context.Context, keyRef string) (string, error) {
+	// Set default return value
+	var key string
+
+	// Get database object
+	dbInfo, err := coreDB.GetDatabase("internal", nil)
+	if err != nil {
+		return key, err
+	}
+
+	// Execute query (get a key referenced by de-identification options)
+	querySyntax := `SELECT key_value FROM did_key WHERE key_id=?`
+	if dbInfo.Tracking {
+		err = dbInfo.Instance.QueryRowContext(ctx, querySyntax, keyRef).Scan(&key)
+	} else {
+		err = dbInfo.Instance.QueryRow(querySyntax, keyRef).Scan(&key)
+	}
+	// Catch error
+	if err == sql.ErrNoRows {
+		return key, errors.New("Not found key (Please check if the key reference is correct)")
+	}
+	return key, err
+}
+
+func In_getToken(ctx context.Context, domain string, valueHash string) (string, error) {
+	// Set default return value
+	var token string
+
+	// Get database object
+	dbInfo, err := coreDB.GetDatabase("internal", nil)
+	if err != nil {
+		return token, err
+	}
+
+	// Execute query (get a token mapped to the value)
+	querySyntax := `SELECT token FROM token_vault WHERE token_domain=? AND value_hash=?`
+	if dbInfo.Tracking {
+		err = dbInfo.Instance.QueryRowContext(ctx, querySyntax, domain, valueHash).Scan(&token)
+	} else {
+		err = dbInfo.Instance.QueryRow(querySyntax, domain, valueHash).Scan(&token)
+	}
+	// Not issued yet
+	if err == sql.ErrNoRows {
+		return token, nil
+	}
+	return token, err
+}
+
+func In_storeToken(ctx context.Context, domain string, valueHash string, value string, token string) (string, error) {
+	// Get database object
+	dbInfo, err := coreDB.GetDatabase("internal", nil)
+	if err != nil {
+		return "", err
+	}
+
+	// Execute query (ignored if the value or token already exists in the domain)
+	var result sql.Result
+	querySyntax := `INSERT IGNORE INTO token_vault (token_domain, token, value_hash, token_value) VALUE (?, ?, ?, ?)`
+	if dbInfo.Tracking {
+		result, err = dbInfo.Instance.ExecContext(ctx, querySyntax, domain, token, valueHash, value)
+	} else {
+		result, err = dbInfo.Instance.Exec(querySyntax, domain, token, valueHash, value)
+	}
+	// Catch error
+	if err != nil {
+		return "", err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return "", err
+	} else if affected == 0 {
+		// Conflict: the token issued by another process first (empty string if the token is mapped to another value)
+		return In_getToken(ctx, domain, valueHash)
+	}
+
+	// Verify that the value and token are unique in the domain (the unique indexes of token_vault table are required)
+	var count int64
+	querySyntax = `SELECT COUNT(*) FROM token_vault WHERE token_domain=? AND (value_hash=? OR token=?)`
+	if dbInfo.Tracking {
+		err = dbInfo.Instance.QueryRowContext(ctx, querySyntax, domain, valueHash, token).Scan(&count)
+	} else {
+		err = dbInfo.Instance.QueryRow(querySyntax, domain, valueHash, token).Scan(&count)
+	}
+	if err != nil {
+		return "", err
+	} else if count > 1 {
+		return "", errors.New("Duplicated token mapping (Please check the unique indexes of token_vault table)")
+	}
+	return token, nil
+}
+
+func In_getTokenValue(ctx context.Context, domain string, token string) (string, error) {
+	// Set default return value
+	var value string
+
+	// Get database object
+	dbInfo, err := coreDB.GetDatabase("internal", nil)
+	if err != nil {
+		return value, err
+	}
+
+	// Execute query (get a value mapped to the token)
+	querySyntax := `SELECT token_value FROM token_vault WHERE token_domain=? AND token=?`
+	if dbInfo.Tracking {
+		err = dbInfo.Instance.QueryRowContext(ctx, querySyntax, domain, token).Scan(&value)
+	} else {
+		err = dbInfo.Instance.QueryRow(querySyntax, domain, token).Scan(&value)
+	}
+	// Catch error
+	if err == sql.ErrNoRows {
+		return value, errors.New("Not found token (Please check if the token and domain are correct)")
+	}
+	return value, err
+}
+
+func In_getHierarchy(ctx context.Context, name string) (map[string]string, error) {
+	// Set default return value
+	hierarchy := make(map[string]string)
+
+	// Get database object
+	dbInfo, err := coreDB.GetDatabase("internal", nil)
+	if err != nil {
+		return hierarchy, err
+	}
+
+	// Execute query (get nodes of the hierarchy)
+	var rows *sql.Rows
+	querySyntax := `SELECT n.node_value, IFNULL(n.parent_value, '') FROM hierarchy AS h INNER JOIN hierarchy_node AS n ON h.hierarchy_id=n.hierarchy_id WHERE h.hierarchy_name=?`
+	if dbInfo.Tracking {
+		rows, err = dbInfo.Instance.QueryContext(ctx, querySyntax, name)
+	} else {
+		rows, err = dbInfo.Instance.Query(querySyntax, name)
+	}
+	// Catch error
+	if err != nil {
+		return hierarchy, err
+	}
+	defer rows.Close()
+
+	// Extract query result
+	for rows.Next() {
+		var value, parent string
+		if err := rows.Scan(&value, &parent); err != nil {
+			return hierarchy, err
+		}
+		hierarchy[value] = parent
+	}
+	// Catch error
+	if err := rows.Err(); err != nil {
+		return hierarchy, err
+	} else if len(hierarchy) == 0 {
+		return hierarchy, errors.New("Not found hierarchy (Please check if the hierarchy name is correct)")
+	}
+	return hierarchy, nil
+}
+
+func In_getDictionary(ctx context.Context, name string) ([]string, error) {
+	// Set default return value
+	entries := []string{}
+
+	// Get database object
+	dbInfo, err := coreDB.GetDatabase("internal", nil)
+	if err != nil {
+		return entries, err
+	}
+
+	// Execute query (get entries of the dictionary)
+	var rows *sql.Rows
+	querySyntax := `SELECT e.entry_value FROM dictionary AS d INNER JOIN dictionary_entry AS e ON d.dictionary_id=e.dictionary_id WHERE d.dictionary_name=? ORDER BY e.entry_id`
+	if dbInfo.Tracking {
+		rows, err = dbInfo.Instance.QueryContext(ctx, querySyntax, name)
+	} else {
+		rows, err = dbInfo.Instance.Query(querySyntax, name)
+	}
+	// Catch error
+	if err != nil {
+		return entries, err
+	}
+	defer rows.Close()
+
+	// Extract query result
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return entries, err
+		}
+		entries = append(entries, value)
+	}
+	// Catch error
+	if err := rows.Err(); err != nil {
+		return entries, err
+	} else if len(entries) == 0 {
+		return entries, errors.New("Not found dictionary (Please check if the dictionary name is correct)")
+	}
+	return entries, nil
+}
+
+func In_getExportPolicy(ctx context.Context, id string) (string, error) {
+	// Set default return value
+	var policy string
+
+	// Get database object
+	dbInfo, err := coreDB.GetDatabase("internal", nil)
+	if err != nil {
+		return policy, err
+	}
+
+	// Execute query (get a export policy)
+	var rows *sql.Rows
+	querySyntax := `SELECT policy FROM api_policy WHERE api_id=?`
+	if dbInfo.Tracking {
+		rows, err = dbInfo.Instance.QueryContext(ctx, querySyntax, id)
+	} else {
+		rows, err = dbInfo.Instance.Query(querySyntax, id)
+	}
+	// Catch error
+	if err != nil {
+		return policy, err
+	}
+	defer rows.Close()
+
+	// Extract query result
+	for rows.Next() {
+		if err := rows.Scan(&policy); err != nil {
+			return policy, err
+		}
+	}
+
+	// Return
+	return policy, rows.Err()
+}
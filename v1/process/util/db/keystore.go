@@ -0,0 +1,25 @@
+package db
+
+import (
+	"context"
+	"encoding/hex"
+
+	// Util
+	"privacydam-go/v1/process/util/did"
+)
+
+// internalKeyStore loads keys referenced by de-identification options from internal database (did_key table)
+type internalKeyStore struct{}
+
+func (internalKeyStore) LoadKey(ref string) ([]byte, error) {
+	// Get key (stored in hex string)
+	rawKey, err := In_getDeIdentificationKey(context.Background(), ref)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(rawKey)
+}
+
+func init() {
+	did.SetKeyStore(internalKeyStore{})
+}
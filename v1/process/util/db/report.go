@@ -0,0 +1,74 @@
+package db
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	// Model
+	"privacydam-go/v1/core/model"
+)
+
+// didErrorReport counts values that failed de-identification (shared by go-routines)
+type didErrorReport struct {
+	mutex        sync.Mutex
+	columnErrors map[string]int64
+	errorCount   int64
+	suppressed   int64
+	abortFlag    int32
+	abortErr     error
+}
+
+func newDidErrorReport() *didErrorReport {
+	return &didErrorReport{columnErrors: make(map[string]int64)}
+}
+
+func (r *didErrorReport) add(column string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.columnErrors[column]++
+	r.errorCount++
+}
+
+func (r *didErrorReport) suppress() {
+	atomic.AddInt64(&r.suppressed, 1)
+}
+
+// abort stops the export (only the first error is kept)
+func (r *didErrorReport) abort(column string, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.abortErr == nil {
+		r.abortErr = errors.New("De-identification failed (" + column + "): " + err.Error())
+		atomic.StoreInt32(&r.abortFlag, 1)
+	}
+}
+
+// fail stops the export by an internal error (only the first error is kept)
+func (r *didErrorReport) fail(err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.abortErr == nil {
+		r.abortErr = err
+		atomic.StoreInt32(&r.abortFlag, 1)
+	}
+}
+
+func (r *didErrorReport) aborted() bool {
+	return atomic.LoadInt32(&r.abortFlag) == 1
+}
+
+// apply sets the counts in evaluation result and returns abort error (contain nil)
+func (r *didErrorReport) apply(evaluation *model.Evaluation) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	evaluation.ErrorCount = r.errorCount
+	evaluation.ErrorSuppressedRows = atomic.LoadInt64(&r.suppressed)
+	if len(r.columnErrors) > 0 {
+		evaluation.ColumnErrors = make(map[string]int64, len(r.columnErrors))
+		for column, count := range r.columnErrors {
+			evaluation.ColumnErrors[column] = count
+		}
+	}
+	return r.abortErr
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"encoding/csv"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+)
+
+// rowSpool holds exported rows in memory and spills them to a temporary file over the limit (for buffered export)
+type rowSpool struct {
+	limit  int
+	rows   [][]string
+	file   *os.File
+	writer *csv.Writer
+}
+
+func newRowSpool() *rowSpool {
+	// Get the number of rows to hold in memory from environment various (default: 100,000)
+	limit, err := strconv.ParseInt(os.Getenv("EXPORT_MEMORY_ROWS"), 10, 0)
+	if err != nil || limit < 0 {
+		limit = 100000
+	}
+	return &rowSpool{limit: int(limit)}
+}
+
+func (s *rowSpool) add(row []string) error {
+	if s.file == nil && len(s.rows) < s.limit {
+		s.rows = append(s.rows, row)
+		return nil
+	}
+	// Spill to temporary file
+	if s.file == nil {
+		file, err := ioutil.TempFile("", "privacydam-export-")
+		if err != nil {
+			return err
+		}
+		s.file, s.writer = file, csv.NewWriter(file)
+	}
+	return s.writer.Write(row)
+}
+
+// each calls fn for each row in the order added (index is the order of row)
+func (s *rowSpool) each(fn func(index int, row []string) error) error {
+	for i, row := range s.rows {
+		if err := fn(i, row); err != nil {
+			return err
+		}
+	}
+	if s.file == nil {
+		return nil
+	}
+
+	// Read spilled rows
+	s.writer.Flush()
+	if err := s.writer.Error(); err != nil {
+		return err
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	reader := csv.NewReader(s.file)
+	reader.FieldsPerRecord = -1
+	for index := len(s.rows); ; index++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if err := fn(index, row); err != nil {
+			return err
+		}
+	}
+	// Seek the end of file (to add more rows)
+	_, err := s.file.Seek(0, io.SeekEnd)
+	return err
+}
+
+// close releases rows and removes temporary file
+func (s *rowSpool) close() {
+	s.rows = nil
+	if s.file != nil {
+		s.file.Close()
+		os.Remove(s.file.Name())
+		s.file, s.writer = nil, nil
+	}
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+// collectSpool returns the rows of spool in the order of index
+func collectSpool(t *testing.T, spool *rowSpool) [][]string {
+	rows := [][]string{}
+	err := spool.each(func(index int, row []string) error {
+		if index != len(rows) {
+			t.Fatalf("index = %d, want %d", index, len(rows))
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestRowSpoolSpill(t *testing.T) {
+	t.Setenv("EXPORT_MEMORY_ROWS", "2")
+	spool := newRowSpool()
+	defer spool.close()
+
+	// Values that need quoting in CSV and rows of different length (fallback values)
+	rows := [][]string{
+		{"1", "kim", "seoul"},
+		{"2", "lee, jr.", ""},
+		{"3", `say "hi"`, "line\nbreak"},
+		{"4", "", "", "20 ~ 40", "*"},
+		{"5", " space ", "한글"},
+	}
+	for _, row := range rows[:4] {
+		if err := spool.add(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if spool.file == nil {
+		t.Fatal("rows over the limit are not spilled")
+	}
+	if output := collectSpool(t, spool); !reflect.DeepEqual(output, rows[:4]) {
+		t.Errorf("rows = %q, want %q", output, rows[:4])
+	}
+	// Rows can be added after reading, and read again
+	if err := spool.add(rows[4]); err != nil {
+		t.Fatal(err)
+	}
+	if output := collectSpool(t, spool); !reflect.DeepEqual(output, rows) {
+		t.Errorf("rows = %q, want %q", output, rows)
+	}
+
+	// Temporary file is removed
+	name := spool.file.Name()
+	spool.close()
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("temporary file %s is not removed (%v)", name, err)
+	}
+}
+
+func TestRowSpoolMemory(t *testing.T) {
+	t.Setenv("EXPORT_MEMORY_ROWS", "")
+	spool := newRowSpool()
+	defer spool.close()
+	spool.add([]string{"1"})
+	if spool.file != nil || spool.limit != 100000 {
+		t.Errorf("limit = %d, spilled = %t", spool.limit, spool.file != nil)
+	}
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	// Util
+	"privacydam-go/v1/process/util/did"
+)
+
+// internalTokenVault stores the mapping between tokens and values in internal database (token_vault table)
+// Tokens are unique and deterministic in a domain by the unique indexes of token_vault table
+//
+//	CREATE TABLE token_vault (
+//		token_domain VARCHAR(64) NOT NULL,
+//		token VARCHAR(255) NOT NULL,
+//		value_hash CHAR(64) NOT NULL,
+//		token_value TEXT NOT NULL,
+//		UNIQUE KEY uk_token_vault_value (token_domain, value_hash),
+//		UNIQUE KEY uk_token_vault_token (token_domain, token)
+//	);
+type internalTokenVault struct{}
+
+// hashValue creates a lookup key for the value
+func hashValue(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])
+}
+
+func (internalTokenVault) LoadToken(domain string, value string) (string, error) {
+	return In_getToken(context.Background(), domain, hashValue(value))
+}
+
+func (internalTokenVault) StoreToken(domain string, value string, token string) (string, error) {
+	return In_storeToken(context.Background(), domain, hashValue(value), value, token)
+}
+
+func (internalTokenVault) LoadValue(domain string, token string) (string, error) {
+	return In_getTokenValue(context.Background(), domain, token)
+}
+
+func init() {
+	did.SetTokenVault(internalTokenVault{})
+}
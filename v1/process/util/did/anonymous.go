@@ -7,9 +7,9 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math"
-	"regexp"
+	"sort"
 	"strconv"
-	"strings"
+	"time"
 
 	// Model
 	model "privacydam-go/v1/core/model"
@@ -27,54 +27,83 @@ const (
 	ErrorInternal     = 10 // mapping function execution error
 )
 
+func validateEncryptingOptions(options model.AnoOption) error {
+	switch options.Algorithm {
+	case "hmac":
+		switch options.Digest {
+		case "sha256", "md5", "":
+			return nil
+		default:
+			return newOptionError("digest", "unknown digest")
+		}
+	case "hash(sha256)", "hash(md5)":
+		return nil
+	case "fpe":
+		return validateFpeOptions(options)
+	default:
+		return newOptionError("algorithm", "unknown Encrypting algorithm")
+	}
+}
+
 func BuildEncryptingFunc(options model.AnoOption) func(string) string {
+	return buildOrError(newEncryptingFunc(options))
+}
+
+func newEncryptingFunc(options model.AnoOption) (func(string) (string, error), error) {
+	if err := validateEncryptingOptions(options); err != nil {
+		return nil, err
+	}
 	switch options.Algorithm {
 	case "hmac":
 		switch options.Digest {
 		case "sha256":
 			mac := hmac.New(sha256.New, []byte(options.Key))
-			return func(inString string) string {
+			return func(inString string) (string, error) {
 				mac.Write([]byte(inString))
 				defer mac.Reset()
-				return hex.EncodeToString(mac.Sum(nil))
-			}
+				return hex.EncodeToString(mac.Sum(nil)), nil
+			}, nil
 		case "md5":
 			mac := hmac.New(md5.New, []byte(options.Key))
-			return func(inString string) string {
+			return func(inString string) (string, error) {
 				mac.Write([]byte(inString))
 				defer mac.Reset()
-				return hex.EncodeToString(mac.Sum(nil))
-			}
+				return hex.EncodeToString(mac.Sum(nil)), nil
+			}, nil
 		default:
 			mac := hmac.New(sha256.New, []byte(options.Key))
-			return func(inString string) string {
+			return func(inString string) (string, error) {
 				mac.Write([]byte(inString))
 				defer mac.Reset()
-				return hex.EncodeToString(mac.Sum(nil))
-			}
+				return hex.EncodeToString(mac.Sum(nil)), nil
+			}, nil
 		}
 	case "hash(sha256)":
 		mac := sha256.New()
-		return func(inString string) string {
+		return func(inString string) (string, error) {
 			mac.Write([]byte(inString))
 			defer mac.Reset()
-			return hex.EncodeToString(mac.Sum(nil))
-		}
+			return hex.EncodeToString(mac.Sum(nil)), nil
+		}, nil
+	case "fpe":
+		return newFpeEncryptingFunc(options)
 	case "hash(md5)":
 		mac := md5.New()
-		return func(inString string) string {
+		return func(inString string) (string, error) {
 			mac.Write([]byte(inString))
 			defer mac.Reset()
-			return hex.EncodeToString(mac.Sum(nil))
-		}
+			return hex.EncodeToString(mac.Sum(nil)), nil
+		}, nil
 	default:
-		return func(inString string) string {
-			return "unknown Encrypting algorithm"
-		}
+		return nil, newOptionError("algorithm", "unknown Encrypting algorithm")
 	}
 }
 
 func BuildRoundingFunc(options model.AnoOption) func(string) string {
+	return buildOrError(newRoundingFunc(options))
+}
+
+func newRoundingFunc(options model.AnoOption) (func(string) (string, error), error) {
 	/*position, err := strconv.ParseInt(options.Position, 10, 0)
 	if err != nil {
 		return func (inString string) string {
@@ -85,126 +114,340 @@ func BuildRoundingFunc(options model.AnoOption) func(string) string {
 	posPower := math.Pow(10, math.Abs(float64(position)))
 	switch options.Algorithm {
 	case "round":
-		return func(inString string) string {
+		return func(inString string) (string, error) {
 			if value, err := strconv.ParseFloat(inString, 64); err == nil {
 				if position > 0 {
-					return strconv.FormatFloat(math.Round(value*posPower)/posPower, 'f', position, 64)
+					return strconv.FormatFloat(math.Round(value*posPower)/posPower, 'f', position, 64), nil
 				}
-				return strconv.FormatFloat(math.Round(value/posPower)*posPower, 'f', 0, 64)
+				return strconv.FormatFloat(math.Round(value/posPower)*posPower, 'f', 0, 64), nil
 			}
-			return "parseFloat error:" + inString
-		}
+			return "", errParseFloat
+		}, nil
 	case "ceil":
-		return func(inString string) string {
+		return func(inString string) (string, error) {
 			if value, err := strconv.ParseFloat(inString, 64); err == nil {
 				if position > 0 {
-					return strconv.FormatFloat(math.Ceil(value*posPower)/posPower, 'f', position, 64)
+					return strconv.FormatFloat(math.Ceil(value*posPower)/posPower, 'f', position, 64), nil
 				}
-				return strconv.FormatFloat(math.Ceil(value/posPower)*posPower, 'f', 0, 64)
+				return strconv.FormatFloat(math.Ceil(value/posPower)*posPower, 'f', 0, 64), nil
 			}
-			return "parseFloat error:" + inString
+			return "", errParseFloat
 
-		}
+		}, nil
 	case "floor":
-		return func(inString string) string {
+		return func(inString string) (string, error) {
 			if value, err := strconv.ParseFloat(inString, 64); err == nil {
 				if position > 0 {
-					return strconv.FormatFloat(math.Floor(value*posPower)/posPower, 'f', position, 64)
+					return strconv.FormatFloat(math.Floor(value*posPower)/posPower, 'f', position, 64), nil
 				}
-				return strconv.FormatFloat(math.Floor(value/posPower)*posPower, 'f', 0, 64)
+				return strconv.FormatFloat(math.Floor(value/posPower)*posPower, 'f', 0, 64), nil
 			}
-			return "parseFloat error:" + inString
-		}
+			return "", errParseFloat
+		}, nil
 	default:
-		return func(inString string) string {
-			return "unknown Rounding algorithm"
+		return nil, newOptionError("algorithm", "unknown Rounding algorithm")
+	}
+}
+
+// validateRangingOptions verifies options (quantile boundaries are computed by the pre-pass of export)
+func validateRangingOptions(options model.AnoOption) error {
+	if options.Algorithm == "quantile" && len(options.Boundaries) == 0 {
+		binNum, err := strconv.ParseInt(options.Bin, 10, 0)
+		if err != nil || binNum < 1 {
+			return newOptionError("bin", "number of bins (>= 1)")
+		} else if len(options.Labels) != 0 && len(options.Labels) != int(binNum) {
+			// Labels are selected by bin index when boundaries are computed
+			return newOptionError("labels", "a label for each bin")
 		}
+		return nil
 	}
+	_, err := newRangingFunc(options)
+	return err
 }
 
 func BuildRangingFunc(options model.AnoOption) func(string) string {
-	lowBound, err := strconv.ParseFloat(options.Lower, 64)
-	if err != nil {
-		return func(inString string) string {
-			return "lower parameter error"
+	return buildOrError(newRangingFunc(options))
+}
+
+func newRangingFunc(options model.AnoOption) (func(string) (string, error), error) {
+	boundary := []float64{}
+	if len(options.Boundaries) > 0 {
+		// Custom boundaries (or computed boundaries by quantile pre-pass)
+		for _, rawBound := range options.Boundaries {
+			bound, err := strconv.ParseFloat(rawBound, 64)
+			if err != nil || (len(boundary) > 0 && bound <= boundary[len(boundary)-1]) {
+				return nil, newOptionError("boundaries", "")
+			}
+			boundary = append(boundary, bound)
 		}
-	}
-	upBound, err2 := strconv.ParseFloat(options.Upper, 64)
-	if err2 != nil {
-		return func(inString string) string {
-			return "upper parameter error"
+	} else if options.Algorithm == "quantile" {
+		return nil, newOptionError("boundaries", "quantile boundaries are not computed")
+	} else {
+		lowBound, err := strconv.ParseFloat(options.Lower, 64)
+		if err != nil {
+			return nil, newOptionError("lower", "")
 		}
-	}
-	binNumP, err3 := strconv.ParseInt(options.Bin, 10, 0)
-	if err3 != nil {
-		return func(inString string) string {
-			return "bin parameter error"
+		upBound, err2 := strconv.ParseFloat(options.Upper, 64)
+		if err2 != nil {
+			return nil, newOptionError("upper", "")
+		}
+		binNumP, err3 := strconv.ParseInt(options.Bin, 10, 0)
+		if err3 != nil {
+			return nil, newOptionError("bin", "")
 		}
+		binNum := int(binNumP)
+		//boundary :=
+		for i := 0; i < binNum; i++ {
+			boundary = append(boundary, lowBound+((upBound-lowBound)/float64(binNum))*float64(i))
+		}
+		boundary = append(boundary, upBound)
 	}
-	binNum := int(binNumP)
-	boundary := []float64{}
-	//boundary :=
-	for i := 0; i < binNum; i++ {
-		boundary = append(boundary, lowBound+((upBound-lowBound)/float64(binNum))*float64(i))
+
+	// Labels for each band (the band starts at each boundary, and optionally the band below the first boundary)
+	// Without the label for the band below the first boundary, the values below the first boundary are errors (processed by error policy)
+	labels := options.Labels
+	labelOffset := 0
+	if len(labels) == len(boundary)+1 {
+		labelOffset = 1
+	} else if len(labels) != 0 && len(labels) != len(boundary) {
+		return nil, newOptionError("labels", "")
 	}
-	boundary = append(boundary, upBound)
 
-	return func(inString string) string {
+	return func(inString string) (string, error) {
 		if value, err := strconv.ParseFloat(inString, 64); err == nil {
 			before := ""
 			last := ""
-			for _, bound := range boundary {
+			for i, bound := range boundary {
 				if bound > value {
-					return fmt.Sprint(before, " ~ ", bound)
+					if len(labels) > 0 {
+						if i+labelOffset == 0 {
+							return "", errOutOfRange
+						}
+						return labels[i+labelOffset-1], nil
+					}
+					return fmt.Sprint(before, " ~ ", bound), nil
 				}
 				before = fmt.Sprintf("%v", bound) //bound
 				last = fmt.Sprintf("%v", bound)
 			}
-			return fmt.Sprint(last, " ~ ")
+			if len(labels) > 0 {
+				return labels[len(labels)-1], nil
+			}
+			return fmt.Sprint(last, " ~ "), nil
+		}
+		return "", errParseFloat
+	}, nil
+}
+
+// ComputeQuantileBoundaries computes boundaries so that each bin holds a similar number of values
+// Duplicated boundaries are merged, and the labels of bins (optional, a label for each bin) are selected by bin index
+func ComputeQuantileBoundaries(values []float64, binNum int, labels []string) ([]string, []string) {
+	boundaries := []string{}
+	selected := []string{}
+	if binNum < 1 {
+		return boundaries, selected
+	} else if len(values) == 0 {
+		// No value to compute (a boundary that no value reaches)
+		boundaries = append(boundaries, strconv.FormatFloat(math.Inf(1), 'f', -1, 64))
+		if len(labels) > 0 {
+			selected = append(selected, labels[0])
+		}
+		return boundaries, selected
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+
+	last := math.Inf(-1)
+	for i := 0; i < binNum; i++ {
+		bound := sorted[i*len(sorted)/binNum]
+		// Skip duplicated boundaries (a large number of same values, the label of the first bin is used)
+		if bound > last {
+			boundaries = append(boundaries, strconv.FormatFloat(bound, 'f', -1, 64))
+			if i < len(labels) {
+				selected = append(selected, labels[i])
+			}
+			last = bound
 		}
-		return "parseFloat error:" + inString
 	}
+	return boundaries, selected
 }
 
-func BuildMaskingFunc(options model.AnoOption) func(string) string {
-	//maskPattern = '(^.{{{startlen}}})(.*)(.{{{endlen}}}$)'
-	fore, err := strconv.ParseInt(options.Fore, 10, 0)
-	if err != nil {
-		return func(inString string) string {
-			return "fore parameter error"
+func BuildTopBottomCodingFunc(options model.AnoOption) func(string) string {
+	return buildOrError(newTopBottomCodingFunc(options))
+}
+
+func newTopBottomCodingFunc(options model.AnoOption) (func(string) (string, error), error) {
+	if options.Lower == "" && options.Upper == "" {
+		return nil, newOptionError("lower", "lower or upper is required")
+	}
+	// Thresholds (one side can be omitted)
+	lowBound, upBound := math.Inf(-1), math.Inf(1)
+	var err error
+	if options.Lower != "" {
+		if lowBound, err = strconv.ParseFloat(options.Lower, 64); err != nil {
+			return nil, newOptionError("lower", "")
+		}
+	}
+	if options.Upper != "" {
+		if upBound, err = strconv.ParseFloat(options.Upper, 64); err != nil || upBound < lowBound {
+			return nil, newOptionError("upper", "")
+		}
+	}
+
+	// Set replacement values by algorithm
+	var bottomCode, topCode string
+	switch options.Algorithm {
+	case "threshold", "":
+		bottomCode, topCode = options.Lower, options.Upper
+	case "label":
+		bottomCode, topCode = "<="+options.Lower, ">="+options.Upper
+		if options.BottomLabel != "" {
+			bottomCode = options.BottomLabel
+		}
+		if options.TopLabel != "" {
+			topCode = options.TopLabel
+		}
+	default:
+		return nil, newOptionError("algorithm", "unknown TopBottomCoding algorithm")
+	}
+
+	return func(inString string) (string, error) {
+		if inString == "" {
+			return "", nil
+		}
+		if value, err := strconv.ParseFloat(inString, 64); err == nil {
+			if value < lowBound {
+				return bottomCode, nil
+			} else if value > upBound {
+				return topCode, nil
+			}
+			return inString, nil
+		}
+		return "", errParseFloat
+	}, nil
+}
+
+// layouts that can be generalized (the first one is the format used by transformToString)
+var dateLayouts = []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+func BuildDateGeneralizingFunc(options model.AnoOption) func(string) string {
+	return buildOrError(newDateGeneralizingFunc(options))
+}
+
+func newDateGeneralizingFunc(options model.AnoOption) (func(string) (string, error), error) {
+	var truncate func(time.Time) time.Time
+	switch options.Unit {
+	case "year":
+		truncate = func(t time.Time) time.Time {
+			return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+		}
+	case "quarter":
+		truncate = func(t time.Time) time.Time {
+			month := time.Month((int(t.Month())-1)/3*3 + 1)
+			return time.Date(t.Year(), month, 1, 0, 0, 0, 0, t.Location())
 		}
+	case "month":
+		truncate = func(t time.Time) time.Time {
+			return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		}
+	case "week":
+		// weeks start on monday (ISO 8601)
+		truncate = func(t time.Time) time.Time {
+			offset := (int(t.Weekday()) + 6) % 7
+			return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+		}
+	case "day":
+		truncate = func(t time.Time) time.Time {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		}
+	case "", "custom":
+		if options.Format == "" {
+			return nil, newOptionError("format", "")
+		}
+		truncate = func(t time.Time) time.Time {
+			return t
+		}
+	default:
+		return nil, newOptionError("unit", "unknown DateGeneralization unit")
 	}
-	aft, err1 := strconv.ParseInt(options.Aft, 10, 0)
-	if err1 != nil {
-		return func(inString string) string {
-			return "aft parameter error"
+
+	return func(inString string) (string, error) {
+		if inString == "" {
+			return "", nil
+		}
+		for _, layout := range dateLayouts {
+			if value, err := time.Parse(layout, inString); err == nil {
+				// keep the input layout unless a custom pattern is given
+				if options.Format != "" {
+					layout = options.Format
+				}
+				return truncate(value).Format(layout), nil
+			}
 		}
+		return "", errParseTime
+	}, nil
+}
+
+func BuildMaskingFunc(options model.AnoOption) func(string) string {
+	return buildOrError(newMaskingFunc(options))
+}
+
+func newMaskingFunc(options model.AnoOption) (func(string) (string, error), error) {
+	maskChar := []rune(options.MaskChar)
+	if len(maskChar) == 0 {
+		maskChar = []rune("*")
 	}
-	maskChar := options.MaskChar
 	keepLength, err2 := strconv.ParseBool(options.KeepLength)
 	if err2 != nil {
-		return func(inString string) string {
-			return "keepLength parameter error"
+		return nil, newOptionError("keepLength", "")
+	}
+
+	// Set a function to select the segment to mask (count by characters, not bytes)
+	var segment func(length int) (int, int, bool)
+	if options.Percent != "" {
+		// Mask a middle segment by percentage
+		percent, err := strconv.ParseFloat(options.Percent, 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, newOptionError("percent", "")
+		}
+		segment = func(length int) (int, int, bool) {
+			maskLen := int(math.Round(float64(length) * percent / 100))
+			fore := (length - maskLen) / 2
+			return fore, fore + maskLen, true
+		}
+	} else {
+		fore, err := strconv.ParseInt(options.Fore, 10, 0)
+		if err != nil || fore < 0 {
+			return nil, newOptionError("fore", "")
+		}
+		aft, err1 := strconv.ParseInt(options.Aft, 10, 0)
+		if err1 != nil || aft < 0 {
+			return nil, newOptionError("aft", "")
+		}
+		segment = func(length int) (int, int, bool) {
+			return int(fore), length - int(aft), length >= int(fore+aft)
 		}
 	}
-	reString := fmt.Sprintf("(^.{%v})(.*)(.{%v}$)", fore, aft)
-	re := regexp.MustCompile(reString)
 
-	//reObject = re.compile(maskPattern.format(startlen=fore, endlen=aft))
-	return func(inString string) string {
+	return func(inString string) (string, error) {
 		if inString == "" {
-			return ""
-		}
-		if len(inString) >= int(fore+aft) {
-			resIndex := re.FindStringSubmatchIndex(inString)
-			if keepLength {
-				maskLen := resIndex[5] - resIndex[4]
-				repeatNum := math.Ceil(float64(maskLen / len(maskChar)))
-				mask := strings.Repeat(maskChar, int(repeatNum))
-				return inString[resIndex[2]:resIndex[3]] + mask[0:maskLen] + inString[resIndex[6]:resIndex[7]]
+			return "", nil
+		}
+		runes := []rune(inString)
+		start, end, ok := segment(len(runes))
+		if !ok {
+			return "", nil
+		}
+		var mask []rune
+		if keepLength {
+			maskLen := end - start
+			mask = make([]rune, maskLen)
+			for i := range mask {
+				mask[i] = maskChar[i%len(maskChar)]
 			}
-			return inString[resIndex[2]:resIndex[3]] + maskChar + inString[resIndex[6]:resIndex[7]]
+		} else {
+			mask = maskChar
 		}
-		return ""
-	}
+		return string(runes[:start]) + string(mask) + string(runes[end:]), nil
+	}, nil
 }
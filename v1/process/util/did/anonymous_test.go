@@ -0,0 +1,68 @@
+package did
+
+import (
+	"reflect"
+	"testing"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+func TestQuantileLabels(t *testing.T) {
+	labels := []string{"Q1", "Q2", "Q3", "Q4"}
+	// Duplicated boundaries are merged (the label of merged bin is not used)
+	values := []float64{1, 1, 1, 1, 2, 3, 4, 5, 100}
+	boundaries, selected := ComputeQuantileBoundaries(values, 4, labels)
+	if !reflect.DeepEqual(boundaries, []string{"1", "2", "4"}) || !reflect.DeepEqual(selected, []string{"Q1", "Q3", "Q4"}) {
+		t.Fatalf("ComputeQuantileBoundaries() = %v, %v", boundaries, selected)
+	}
+
+	fn, err := newRangingFunc(model.AnoOption{Algorithm: "quantile", Bin: "4", Boundaries: boundaries, Labels: selected})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{"1": "Q1", "2": "Q3", "3": "Q3", "4": "Q4", "100": "Q4"}
+	for input, output := range cases {
+		if result, err := fn(input); err != nil || result != output {
+			t.Errorf("range(%s) = %s, %v, want %s", input, result, err, output)
+		}
+	}
+	// Values below the first boundary are errors
+	if _, err := fn("0"); err != errOutOfRange {
+		t.Errorf("range(0) error = %v, want %v", err, errOutOfRange)
+	}
+}
+
+func TestQuantileValidation(t *testing.T) {
+	cases := []struct {
+		options model.AnoOption
+		field   string
+	}{
+		{model.AnoOption{Algorithm: "quantile", Bin: "4"}, ""},
+		{model.AnoOption{Algorithm: "quantile", Bin: "4", Labels: []string{"Q1", "Q2", "Q3", "Q4"}}, ""},
+		{model.AnoOption{Algorithm: "quantile", Bin: "4", Labels: []string{"Q1"}}, "labels"},
+		{model.AnoOption{Algorithm: "quantile", Bin: "0"}, "bin"},
+		{model.AnoOption{Algorithm: "quantile"}, "bin"},
+	}
+	for _, c := range cases {
+		err := validateRangingOptions(c.options)
+		if c.field == "" && err != nil {
+			t.Errorf("validate(%+v) = %v, want nil", c.options, err)
+		} else if optionErr, ok := err.(*OptionError); c.field != "" && (!ok || optionErr.Field != c.field) {
+			t.Errorf("validate(%+v) = %v, want an error of %s", c.options, err, c.field)
+		}
+	}
+}
+
+func TestQuantileNestedFields(t *testing.T) {
+	// Quantile boundaries are not computed for nested fields
+	quantile := model.AnoParamOption{Method: "data_range", Options: model.AnoOption{Algorithm: "quantile", Bin: "4"}}
+	err := validateJsonFieldsOptions(model.AnoOption{Fields: map[string]model.AnoParamOption{"$.age": quantile}})
+	if optionErr, ok := err.(*OptionError); !ok || optionErr.Field != "fields" {
+		t.Errorf("validate() = %v, want an error of fields", err)
+	}
+	quantile.Options.Boundaries = []string{"10", "20"}
+	if err := validateJsonFieldsOptions(model.AnoOption{Fields: map[string]model.AnoParamOption{"$.age": quantile}}); err != nil {
+		t.Errorf("validate() = %v, want nil", err)
+	}
+}
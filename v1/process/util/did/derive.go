@@ -0,0 +1,139 @@
+package did
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+var errDeriveSource = errors.New("Invalid birthdate or resident registration number")
+
+// subject is the source of derived attributes
+type subject struct {
+	birthdate time.Time
+	gender    int // 0: unknown (birthdate), 1: male, 2: female
+}
+
+// parseRrn parses a korean resident registration number (YYMMDD-GNNNNNN)
+func parseRrn(inString string) (subject, bool) {
+	digits := strings.Replace(inString, "-", "", 1)
+	if len(digits) != 13 {
+		return subject{}, false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return subject{}, false
+		}
+	}
+
+	// century and gender by the 7th digit
+	var century, gender int
+	switch digits[6] {
+	case '1', '2', '5', '6':
+		century = 1900
+	case '3', '4', '7', '8':
+		century = 2000
+	case '9', '0':
+		century = 1800
+	}
+	if (digits[6]-'0')%2 == 1 {
+		gender = 1
+	} else {
+		gender = 2
+	}
+
+	birthdate, err := time.Parse("20060102", strconv.Itoa(century/100)+digits[:6])
+	if err != nil {
+		return subject{}, false
+	}
+	return subject{birthdate: birthdate, gender: gender}, true
+}
+
+func parseSubject(inString string) (subject, error) {
+	if s, ok := parseRrn(inString); ok {
+		return s, nil
+	}
+	for _, layout := range append(dateLayouts, "20060102") {
+		if value, err := time.Parse(layout, inString); err == nil {
+			return subject{birthdate: value}, nil
+		}
+	}
+	return subject{}, errDeriveSource
+}
+
+// age returns the age in full years at the reference date
+func age(birthdate time.Time, reference time.Time) int {
+	years := reference.Year() - birthdate.Year()
+	if reference.Month() < birthdate.Month() || (reference.Month() == birthdate.Month() && reference.Day() < birthdate.Day()) {
+		years--
+	}
+	return years
+}
+
+func BuildDerivingFunc(options model.AnoOption) func(string) string {
+	return buildOrError(newDerivingFunc(options))
+}
+
+func newDerivingFunc(options model.AnoOption) (func(string) (string, error), error) {
+	// Reference date for age (default: the date of export)
+	reference := time.Now()
+	if options.ReferenceDate != "" {
+		value, err := time.Parse("2006-01-02", options.ReferenceDate)
+		if err != nil {
+			return nil, newOptionError("referenceDate", "yyyy-mm-dd")
+		}
+		reference = value
+	}
+
+	var derive func(subject) (string, error)
+	switch options.Attribute {
+	case "age":
+		derive = func(s subject) (string, error) {
+			return strconv.Itoa(age(s.birthdate, reference)), nil
+		}
+	case "age_band":
+		// Bin the age with data_range options (lower/upper/bin or boundaries/labels)
+		ranging, err := newRangingFunc(options)
+		if err != nil {
+			return nil, err
+		}
+		derive = func(s subject) (string, error) {
+			return ranging(strconv.Itoa(age(s.birthdate, reference)))
+		}
+	case "birth_year":
+		derive = func(s subject) (string, error) {
+			return strconv.Itoa(s.birthdate.Year()), nil
+		}
+	case "gender":
+		// Labels for male and female
+		labels := []string{"M", "F"}
+		if len(options.Labels) == 2 {
+			labels = options.Labels
+		} else if len(options.Labels) != 0 {
+			return nil, newOptionError("labels", "labels for male and female")
+		}
+		derive = func(s subject) (string, error) {
+			if s.gender == 0 {
+				return "", errDeriveSource
+			}
+			return labels[s.gender-1], nil
+		}
+	default:
+		return nil, newOptionError("attribute", "age, age_band, birth_year or gender")
+	}
+
+	return func(inString string) (string, error) {
+		if inString == "" {
+			return "", nil
+		}
+		s, err := parseSubject(inString)
+		if err != nil {
+			return "", err
+		}
+		return derive(s)
+	}, nil
+}
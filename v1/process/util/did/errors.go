@@ -0,0 +1,95 @@
+package did
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// errors for values that can't be processed
+var (
+	errParseFloat = errors.New("Invalid number format")
+	errParseTime  = errors.New("Invalid date format")
+	errOutOfRange = errors.New("Value out of range")
+)
+
+// OptionError describes an invalid de-identification option
+type OptionError struct {
+	Column string `json:"column,omitempty"`
+	Step   int    `json:"step,omitempty"`
+	Method string `json:"method,omitempty"`
+	Field  string `json:"field"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func newOptionError(field string, reason string) error {
+	return &OptionError{Field: field, Reason: reason}
+}
+
+func (e *OptionError) Error() string {
+	var buffer bytes.Buffer
+	if e.Column != "" {
+		buffer.WriteString("[")
+		buffer.WriteString(e.Column)
+		buffer.WriteString("] ")
+	}
+	if e.Step > 0 {
+		buffer.WriteString("step ")
+		buffer.WriteString(strconv.Itoa(e.Step))
+		buffer.WriteString(" ")
+	}
+	if e.Method != "" {
+		buffer.WriteString(e.Method)
+		buffer.WriteString(": ")
+	}
+	buffer.WriteString(e.Field)
+	buffer.WriteString(" parameter error")
+	if e.Reason != "" {
+		buffer.WriteString(" (")
+		buffer.WriteString(e.Reason)
+		buffer.WriteString(")")
+	}
+	return buffer.String()
+}
+
+// PolicyError is an error of nested options whose policy applies to the whole row (suppress, abort)
+type PolicyError struct {
+	Policy string `json:"policy"`
+	Path   string `json:"path,omitempty"`
+	Err    error  `json:"-"`
+}
+
+func (e *PolicyError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return "[" + e.Path + "] " + e.Err.Error()
+}
+
+// OptionErrors is a list of invalid options (validation result)
+type OptionErrors []*OptionError
+
+func (e OptionErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// buildOrError returns a function for Build*Func (an invalid value is replaced with empty string)
+func buildOrError(fn func(string) (string, error), err error) func(string) string {
+	if err != nil {
+		message := err.Error()
+		return func(inString string) string {
+			return message
+		}
+	}
+	return func(inString string) string {
+		if output, err := fn(inString); err == nil {
+			return output
+		}
+		return ""
+	}
+}
@@ -0,0 +1,432 @@
+package did
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+// default alphabet for format-preserving encryption
+const fpeDefaultAlphabet = "0123456789"
+
+// KeyStore provides the keys referenced by de-identification options (options.KeyRef)
+type KeyStore interface {
+	LoadKey(ref string) ([]byte, error)
+}
+
+var gKeyStore KeyStore
+
+// SetKeyStore sets the key store used to resolve key references
+func SetKeyStore(store KeyStore) {
+	gKeyStore = store
+}
+
+func loadReferencedKey(ref string) ([]byte, error) {
+	if gKeyStore == nil {
+		return nil, errors.New("No key store was registered")
+	}
+	return gKeyStore.LoadKey(ref)
+}
+
+// fpeCipher encrypts/decrypts a numeral string (each numeral is an index of the alphabet)
+type fpeCipher interface {
+	encrypt(x []int) ([]int, error)
+	decrypt(x []int) ([]int, error)
+}
+
+type fpeProcessor struct {
+	cipher   fpeCipher
+	alphabet []rune
+	index    map[rune]int
+}
+
+func parseFpeOptions(options model.AnoOption) ([]rune, map[rune]int, []byte, error) {
+	// Set alphabet
+	alphabet := []rune(options.Alphabet)
+	if len(alphabet) == 0 {
+		alphabet = []rune(fpeDefaultAlphabet)
+	}
+	index := make(map[rune]int, len(alphabet))
+	for i, char := range alphabet {
+		if _, exists := index[char]; exists {
+			return nil, nil, nil, newOptionError("alphabet", "duplicated character")
+		}
+		index[char] = i
+	}
+	if len(alphabet) < 2 || len(alphabet) > 65536 {
+		return nil, nil, nil, newOptionError("alphabet", "")
+	}
+
+	// Verify key reference and tweak
+	if options.Key != "" {
+		return nil, nil, nil, newOptionError("key", "inline key is not allowed (use keyRef)")
+	} else if options.KeyRef == "" {
+		return nil, nil, nil, newOptionError("keyRef", "")
+	}
+	tweak, err := hex.DecodeString(options.Tweak)
+	if err != nil {
+		return nil, nil, nil, newOptionError("tweak", "")
+	}
+	switch options.Mode {
+	case "ff1", "":
+	case "ff3-1":
+		if len(tweak) != 0 && len(tweak) != 7 {
+			return nil, nil, nil, newOptionError("tweak", "FF3-1 requires 56 bits")
+		}
+	default:
+		return nil, nil, nil, newOptionError("mode", "unknown FPE mode")
+	}
+	return alphabet, index, tweak, nil
+}
+
+func validateFpeOptions(options model.AnoOption) error {
+	_, _, _, err := parseFpeOptions(options)
+	return err
+}
+
+func newFpeProcessor(options model.AnoOption) (*fpeProcessor, error) {
+	alphabet, index, tweak, err := parseFpeOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	// Load key
+	key, err := loadReferencedKey(options.KeyRef)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create cipher by mode
+	var fpe fpeCipher
+	if options.Mode == "ff3-1" {
+		fpe, err = newFF31(key, tweak, len(alphabet))
+	} else {
+		fpe, err = newFF1(key, tweak, len(alphabet))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &fpeProcessor{cipher: fpe, alphabet: alphabet, index: index}, nil
+}
+
+// process transforms the characters contained in the alphabet and keeps the others in place
+func (p *fpeProcessor) process(inString string, encrypt bool) (string, error) {
+	runes := []rune(inString)
+	positions := make([]int, 0, len(runes))
+	numerals := make([]int, 0, len(runes))
+	for i, char := range runes {
+		if value, ok := p.index[char]; ok {
+			positions = append(positions, i)
+			numerals = append(numerals, value)
+		}
+	}
+
+	var result []int
+	var err error
+	if encrypt {
+		result, err = p.cipher.encrypt(numerals)
+	} else {
+		result, err = p.cipher.decrypt(numerals)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	for i, position := range positions {
+		runes[position] = p.alphabet[result[i]]
+	}
+	return string(runes), nil
+}
+
+func newFpeEncryptingFunc(options model.AnoOption) (func(string) (string, error), error) {
+	processor, err := newFpeProcessor(options)
+	if err != nil {
+		return nil, err
+	}
+	return func(inString string) (string, error) {
+		if inString == "" {
+			return "", nil
+		}
+		result, err := processor.process(inString, true)
+		if err != nil {
+			return "", err
+		}
+		return result, nil
+	}, nil
+}
+
+func BuildDecryptingFunc(options model.AnoOption) (func(string) (string, error), error) {
+	if options.Algorithm != "fpe" {
+		return nil, errors.New("Only the fpe algorithm can be decrypted")
+	}
+	processor, err := newFpeProcessor(options)
+	if err != nil {
+		return nil, err
+	}
+	return func(inString string) (string, error) {
+		if inString == "" {
+			return "", nil
+		}
+		return processor.process(inString, false)
+	}, nil
+}
+
+/* Numeral string utilities (NIST SP 800-38G) */
+func numRadix(x []int, radix int) *big.Int {
+	result := big.NewInt(0)
+	bigRadix := big.NewInt(int64(radix))
+	for _, numeral := range x {
+		result.Mul(result, bigRadix)
+		result.Add(result, big.NewInt(int64(numeral)))
+	}
+	return result
+}
+
+func strRadix(value *big.Int, radix int, length int) []int {
+	result := make([]int, length)
+	rest := new(big.Int).Set(value)
+	bigRadix := big.NewInt(int64(radix))
+	mod := new(big.Int)
+	for i := length - 1; i >= 0; i-- {
+		rest.DivMod(rest, bigRadix, mod)
+		result[i] = int(mod.Int64())
+	}
+	return result
+}
+
+func reverseNumerals(x []int) []int {
+	result := make([]int, len(x))
+	for i, numeral := range x {
+		result[len(x)-1-i] = numeral
+	}
+	return result
+}
+
+func reverseBytes(x []byte) []byte {
+	result := make([]byte, len(x))
+	for i, b := range x {
+		result[len(x)-1-i] = b
+	}
+	return result
+}
+
+// fixedBytes returns value as a big-endian byte string of the given length
+func fixedBytes(value *big.Int, length int) []byte {
+	result := make([]byte, length)
+	return value.FillBytes(result)
+}
+
+// minimum domain size (radix^minlen >= 1,000,000)
+func checkDomainSize(radix int, length int) error {
+	domain := new(big.Int).Exp(big.NewInt(int64(radix)), big.NewInt(int64(length)), nil)
+	if domain.Cmp(big.NewInt(1000000)) < 0 {
+		return errors.New("input is too short for format-preserving encryption")
+	}
+	return nil
+}
+
+/* FF1 */
+type ff1 struct {
+	block cipher.Block
+	tweak []byte
+	radix int
+}
+
+func newFF1(key []byte, tweak []byte, radix int) (*ff1, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &ff1{block: block, tweak: tweak, radix: radix}, nil
+}
+
+// prf is AES CBC-MAC with a zero IV
+func (f *ff1) prf(data []byte) []byte {
+	result := make([]byte, aes.BlockSize)
+	for i := 0; i < len(data); i += aes.BlockSize {
+		for j := 0; j < aes.BlockSize; j++ {
+			result[j] ^= data[i+j]
+		}
+		f.block.Encrypt(result, result)
+	}
+	return result
+}
+
+func (f *ff1) round(x []int, encrypt bool) ([]int, error) {
+	n := len(x)
+	if n < 2 {
+		return nil, errors.New("input is too short for format-preserving encryption")
+	} else if err := checkDomainSize(f.radix, n); err != nil {
+		return nil, err
+	}
+	u := n / 2
+	v := n - u
+	t := len(f.tweak)
+	// b = ceil(ceil(v * log2(radix)) / 8), d = 4 * ceil(b / 4) + 4
+	bigRadix := big.NewInt(int64(f.radix))
+	b := (new(big.Int).Sub(new(big.Int).Exp(bigRadix, big.NewInt(int64(v)), nil), big.NewInt(1)).BitLen() + 7) / 8
+	d := 4*((b+3)/4) + 4
+
+	// P = [1]^1 || [2]^1 || [1]^1 || [radix]^3 || [10]^1 || [u mod 256]^1 || [n]^4 || [t]^4
+	p := []byte{1, 2, 1, byte(f.radix >> 16), byte(f.radix >> 8), byte(f.radix), 10, byte(u), byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n), byte(t >> 24), byte(t >> 16), byte(t >> 8), byte(t)}
+	// Q = T || [0]^((-t-b-1) mod 16) || [i]^1 || [NUM(B)]^b
+	pad := ((-t-b-1)%16 + 16) % 16
+	q := make([]byte, t+pad+1+b)
+	copy(q, f.tweak)
+
+	a := append([]int{}, x[:u]...)
+	c := append([]int{}, x[u:]...)
+	for k := 0; k < 10; k++ {
+		i := k
+		if !encrypt {
+			i = 9 - k
+		}
+		m := u
+		if i%2 == 1 {
+			m = v
+		}
+		// the numeral string used in the PRF (B on encryption, A on decryption)
+		source, target := c, a
+		if !encrypt {
+			source, target = a, c
+		}
+		q[t+pad] = byte(i)
+		copy(q[t+pad+1:], fixedBytes(numRadix(source, f.radix), b))
+		r := f.prf(append(append([]byte{}, p...), q...))
+		// S = first d bytes of R || CIPH(R xor [1]^16) || CIPH(R xor [2]^16) ...
+		s := append([]byte{}, r...)
+		for j := 1; len(s) < d; j++ {
+			block := make([]byte, aes.BlockSize)
+			counter := fixedBytes(big.NewInt(int64(j)), aes.BlockSize)
+			for l := range block {
+				block[l] = r[l] ^ counter[l]
+			}
+			f.block.Encrypt(block, block)
+			s = append(s, block...)
+		}
+		y := new(big.Int).SetBytes(s[:d])
+		modulus := new(big.Int).Exp(bigRadix, big.NewInt(int64(m)), nil)
+		value := numRadix(target, f.radix)
+		if encrypt {
+			value.Add(value, y)
+		} else {
+			value.Sub(value, y)
+		}
+		value.Mod(value, modulus)
+		if encrypt {
+			a, c = c, strRadix(value, f.radix, m)
+		} else {
+			c, a = a, strRadix(value, f.radix, m)
+		}
+	}
+	return append(a, c...), nil
+}
+
+func (f *ff1) encrypt(x []int) ([]int, error) {
+	return f.round(x, true)
+}
+
+func (f *ff1) decrypt(x []int) ([]int, error) {
+	return f.round(x, false)
+}
+
+/* FF3-1 */
+type ff31 struct {
+	block  cipher.Block
+	tweakL []byte
+	tweakR []byte
+	radix  int
+	maxLen int
+}
+
+func newFF31(key []byte, tweak []byte, radix int) (*ff31, error) {
+	// The key is used with reversed byte order (CIPH_REVB(K))
+	block, err := aes.NewCipher(reverseBytes(key))
+	if err != nil {
+		return nil, err
+	}
+	// Tweak is 56 bits (default: all zero)
+	if len(tweak) == 0 {
+		tweak = make([]byte, 7)
+	}
+	// maxlen = 2 * floor(log_radix(2^96))
+	limit := new(big.Int).Lsh(big.NewInt(1), 96)
+	maxLen := 0
+	for power := big.NewInt(int64(radix)); power.Cmp(limit) <= 0; power.Mul(power, big.NewInt(int64(radix))) {
+		maxLen++
+	}
+	return &ff31{
+		block:  block,
+		tweakL: []byte{tweak[0], tweak[1], tweak[2], tweak[3] & 0xF0},
+		tweakR: []byte{tweak[4], tweak[5], tweak[6], (tweak[3] & 0x0F) << 4},
+		radix:  radix,
+		maxLen: 2 * maxLen,
+	}, nil
+}
+
+func (f *ff31) round(x []int, encrypt bool) ([]int, error) {
+	n := len(x)
+	if n < 2 {
+		return nil, errors.New("input is too short for format-preserving encryption")
+	} else if n > f.maxLen {
+		return nil, errors.New("input is too long for FF3-1")
+	} else if err := checkDomainSize(f.radix, n); err != nil {
+		return nil, err
+	}
+	u := (n + 1) / 2
+	v := n - u
+	bigRadix := big.NewInt(int64(f.radix))
+
+	a := append([]int{}, x[:u]...)
+	c := append([]int{}, x[u:]...)
+	for k := 0; k < 8; k++ {
+		i := k
+		if !encrypt {
+			i = 7 - k
+		}
+		m, w := u, f.tweakR
+		if i%2 == 1 {
+			m, w = v, f.tweakL
+		}
+		source, target := c, a
+		if !encrypt {
+			source, target = a, c
+		}
+		// P = W xor [i]^4 || [NUM(REV(B))]^12
+		p := make([]byte, aes.BlockSize)
+		copy(p, w)
+		p[3] ^= byte(i)
+		copy(p[4:], fixedBytes(numRadix(reverseNumerals(source), f.radix), 12))
+		// S = REVB(CIPH_REVB(K)(REVB(P)))
+		s := reverseBytes(p)
+		f.block.Encrypt(s, s)
+		y := new(big.Int).SetBytes(reverseBytes(s))
+
+		modulus := new(big.Int).Exp(bigRadix, big.NewInt(int64(m)), nil)
+		value := numRadix(reverseNumerals(target), f.radix)
+		if encrypt {
+			value.Add(value, y)
+		} else {
+			value.Sub(value, y)
+		}
+		value.Mod(value, modulus)
+		if encrypt {
+			a, c = c, reverseNumerals(strRadix(value, f.radix, m))
+		} else {
+			c, a = a, reverseNumerals(strRadix(value, f.radix, m))
+		}
+	}
+	return append(a, c...), nil
+}
+
+func (f *ff31) encrypt(x []int) ([]int, error) {
+	return f.round(x, true)
+}
+
+func (f *ff31) decrypt(x []int) ([]int, error) {
+	return f.round(x, false)
+}
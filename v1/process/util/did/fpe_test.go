@@ -0,0 +1,154 @@
+package did
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+// testKeyStore provides fixed keys for tests
+type testKeyStore map[string]string
+
+func (s testKeyStore) LoadKey(ref string) ([]byte, error) {
+	key, exists := s[ref]
+	if !exists {
+		return nil, errors.New("Key does not exist")
+	}
+	return hex.DecodeString(key)
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func toNumerals(s string) []int {
+	x := make([]int, len(s))
+	for i, char := range s {
+		x[i] = int(char - '0')
+	}
+	return x
+}
+
+func fromNumerals(x []int) string {
+	s := make([]byte, len(x))
+	for i, value := range x {
+		s[i] = byte('0' + value)
+	}
+	return string(s)
+}
+
+// checkVector encrypts plaintext, compares with expected ciphertext and decrypts it again
+func checkVector(t *testing.T, fpe fpeCipher, plaintext string, ciphertext string) {
+	result, err := fpe.encrypt(toNumerals(plaintext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output := fromNumerals(result); output != ciphertext {
+		t.Errorf("encrypt(%s) = %s, want %s", plaintext, output, ciphertext)
+	}
+	result, err = fpe.decrypt(toNumerals(ciphertext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output := fromNumerals(result); output != plaintext {
+		t.Errorf("decrypt(%s) = %s, want %s", ciphertext, output, plaintext)
+	}
+}
+
+// NIST SP 800-38G FF1 samples (AES-128, radix 10)
+func TestFF1Samples(t *testing.T) {
+	key := decodeHex(t, "2B7E151628AED2A6ABF7158809CF4F3C")
+	samples := []struct {
+		tweak      string
+		plaintext  string
+		ciphertext string
+	}{
+		{"", "0123456789", "2433477484"},
+		{"39383736353433323130", "0123456789", "6124200773"},
+	}
+	for _, sample := range samples {
+		fpe, err := newFF1(key, decodeHex(t, sample.tweak), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkVector(t, fpe, sample.plaintext, sample.ciphertext)
+	}
+}
+
+// FF3-1 sample (AES-128, radix 10, 56 bits tweak)
+func TestFF31Sample(t *testing.T) {
+	key := decodeHex(t, "2DE79D232DF5585D68CE47882AE256D6")
+	fpe, err := newFF31(key, decodeHex(t, "CBD09280979564"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkVector(t, fpe, "3992520240", "8901801106")
+}
+
+func TestFpeRoundTrip(t *testing.T) {
+	SetKeyStore(testKeyStore{"test": "2B7E151628AED2A6ABF7158809CF4F3C"})
+	defer SetKeyStore(nil)
+
+	cases := []struct {
+		options model.AnoOption
+		input   string
+	}{
+		// Characters that are not in the alphabet are kept in place
+		{model.AnoOption{Algorithm: "fpe", KeyRef: "test"}, "010-1234-5678"},
+		{model.AnoOption{Algorithm: "fpe", KeyRef: "test", Mode: "ff3-1"}, "010-1234-5678"},
+		// Custom alphabet
+		{model.AnoOption{Algorithm: "fpe", KeyRef: "test", Alphabet: "abcdefghijklmnopqrstuvwxyz"}, "hello world"},
+		{model.AnoOption{Algorithm: "fpe", KeyRef: "test", Alphabet: "가나다라마바사아자차카타파하", Tweak: "0102030405060708"}, "가나다-라마바사"},
+	}
+	for _, c := range cases {
+		encrypt, err := newFpeEncryptingFunc(c.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypt, err := BuildDecryptingFunc(c.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encrypted, err := encrypt(c.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encrypted == c.input || len([]rune(encrypted)) != len([]rune(c.input)) {
+			t.Errorf("encrypt(%s) = %s, format is not preserved", c.input, encrypted)
+		}
+		// Passthrough characters are kept in place
+		alphabet := c.options.Alphabet
+		if alphabet == "" {
+			alphabet = fpeDefaultAlphabet
+		}
+		inRunes, outRunes := []rune(c.input), []rune(encrypted)
+		for i, char := range inRunes {
+			if !containsRune(alphabet, char) && outRunes[i] != char {
+				t.Errorf("encrypt(%s) = %s, passthrough character at %d is changed", c.input, encrypted, i)
+			}
+		}
+		decrypted, err := decrypt(encrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decrypted != c.input {
+			t.Errorf("decrypt(%s) = %s, want %s", encrypted, decrypted, c.input)
+		}
+	}
+}
+
+func containsRune(s string, r rune) bool {
+	for _, char := range s {
+		if char == r {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package did
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+var (
+	errParseCoordinate = errors.New("Invalid coordinate format")
+	errMissingColumn   = errors.New("Column that does not exist in the row")
+)
+
+type geoOptions struct {
+	precision  int     // geohash precision (number of characters)
+	cellSize   float64 // grid cell size (degree), geohash is used if zero
+	centroid   bool
+	geohash    bool   // output geohash string
+	axis       string // axis of the column for a pair of columns (lat or lon)
+	pairColumn string
+}
+
+func parseGeoOptions(options model.AnoOption) (geoOptions, error) {
+	geo := geoOptions{precision: 6}
+	if options.CellSize != "" {
+		value, err := strconv.ParseFloat(options.CellSize, 64)
+		if err != nil || value <= 0 || value > 180 {
+			return geo, newOptionError("cellSize", "0 < cellSize <= 180")
+		}
+		geo.cellSize = value
+	} else if options.Precision != "" {
+		value, err := strconv.ParseInt(options.Precision, 10, 0)
+		if err != nil || value < 1 || value > 12 {
+			return geo, newOptionError("precision", "1 ~ 12")
+		}
+		geo.precision = int(value)
+	}
+
+	switch options.Mode {
+	case "snap", "":
+	case "centroid":
+		geo.centroid = true
+	default:
+		return geo, newOptionError("mode", "snap or centroid")
+	}
+
+	switch options.Format {
+	case "":
+	case "geohash":
+		if geo.cellSize != 0 {
+			return geo, newOptionError("format", "geohash format can not be used with cellSize")
+		}
+		geo.geohash = true
+	default:
+		return geo, newOptionError("format", "unknown format")
+	}
+
+	// Pair of columns (declared by the axis of column and the name of paired column)
+	switch options.Axis {
+	case "":
+		if options.PairColumn != "" {
+			return geo, newOptionError("axis", "lat or lon (required for pairColumn)")
+		}
+	case "lat", "lon":
+		if options.PairColumn == "" {
+			return geo, newOptionError("pairColumn", "required for axis")
+		}
+		geo.axis, geo.pairColumn = options.Axis, options.PairColumn
+	default:
+		return geo, newOptionError("axis", "lat or lon")
+	}
+	return geo, nil
+}
+
+// geohashCell returns the bounds of the geohash cell that contains the coordinate
+func geohashCell(lat float64, lon float64, precision int) (float64, float64, float64, float64, string) {
+	minLat, maxLat, minLon, maxLon := -90.0, 90.0, -180.0, 180.0
+	hash := make([]byte, precision)
+	for i := 0; i < precision*5; i++ {
+		var bit int
+		if i%2 == 0 {
+			if mid := (minLon + maxLon) / 2; lon >= mid {
+				minLon, bit = mid, 1
+			} else {
+				maxLon = mid
+			}
+		} else {
+			if mid := (minLat + maxLat) / 2; lat >= mid {
+				minLat, bit = mid, 1
+			} else {
+				maxLat = mid
+			}
+		}
+		hash[i/5] = hash[i/5]<<1 | byte(bit)
+	}
+	for i := range hash {
+		hash[i] = geohashBase32[hash[i]]
+	}
+	return minLat, maxLat, minLon, maxLon, string(hash)
+}
+
+// gridCell returns the lower bound of the grid cell that contains the value
+func gridCell(value float64, size float64, upBound float64) float64 {
+	lower := math.Floor(value/size) * size
+	if lower >= upBound {
+		lower -= size
+	}
+	return lower
+}
+
+func formatCoordinate(value float64) string {
+	return strconv.FormatFloat(math.Round(value*1e9)/1e9, 'f', -1, 64)
+}
+
+// generalize returns the generalized coordinate (or geohash)
+func (geo geoOptions) generalize(lat float64, lon float64) (string, string, string) {
+	var minLat, maxLat, minLon, maxLon float64
+	var hash string
+	if geo.cellSize != 0 {
+		minLat, minLon = gridCell(lat, geo.cellSize, 90), gridCell(lon, geo.cellSize, 180)
+		maxLat, maxLon = math.Min(minLat+geo.cellSize, 90), math.Min(minLon+geo.cellSize, 180)
+	} else {
+		minLat, maxLat, minLon, maxLon, hash = geohashCell(lat, lon, geo.precision)
+	}
+	if geo.centroid {
+		lat, lon = (minLat+maxLat)/2, (minLon+maxLon)/2
+	} else {
+		lat, lon = minLat, minLon
+	}
+	return formatCoordinate(lat), formatCoordinate(lon), hash
+}
+
+func parseCoordinate(latString string, lonString string) (float64, float64, error) {
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latString), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, errParseCoordinate
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonString), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, errParseCoordinate
+	}
+	return lat, lon, nil
+}
+
+func BuildGeoGeneralizingFunc(options model.AnoOption) func(string) string {
+	return buildOrError(newGeoGeneralizingFunc(options))
+}
+
+// newGeoGeneralizingFunc creates a function for a single "lat,lon" column
+func newGeoGeneralizingFunc(options model.AnoOption) (func(string) (string, error), error) {
+	geo, err := parseGeoOptions(options)
+	if err != nil {
+		return nil, err
+	} else if geo.pairColumn != "" {
+		return nil, newOptionError("pairColumn", "pair of columns can only be processed with row context")
+	}
+	fn := geo.rowFunc()
+	return func(inString string) (string, error) {
+		return fn(inString, Row{})
+	}, nil
+}
+
+func (geo geoOptions) rowFunc() func(string, Row) (string, error) {
+	return func(inString string, row Row) (string, error) {
+		if inString == "" {
+			return "", nil
+		}
+
+		var latString, lonString string
+		if geo.pairColumn == "" {
+			parts := strings.Split(inString, ",")
+			if len(parts) != 2 {
+				return "", errParseCoordinate
+			}
+			latString, lonString = parts[0], parts[1]
+		} else {
+			pairString, exists := row.Value(geo.pairColumn)
+			if !exists {
+				return "", errMissingColumn
+			}
+			if geo.axis == "lat" {
+				latString, lonString = inString, pairString
+			} else {
+				latString, lonString = pairString, inString
+			}
+		}
+		lat, lon, err := parseCoordinate(latString, lonString)
+		if err != nil {
+			return "", err
+		}
+
+		latOutput, lonOutput, hash := geo.generalize(lat, lon)
+		switch {
+		case geo.geohash:
+			return hash, nil
+		case geo.axis == "lat":
+			return latOutput, nil
+		case geo.axis == "lon":
+			return lonOutput, nil
+		default:
+			return latOutput + "," + lonOutput, nil
+		}
+	}
+}
+
+// geoBuilder builds the geo generalization (a single "lat,lon" column or a pair of columns)
+type geoBuilder struct{}
+
+func (geoBuilder) Validate(step model.AnoMethodOption) error {
+	_, err := parseGeoOptions(step.Options)
+	return err
+}
+
+func (geoBuilder) Build(step model.AnoMethodOption) (func(string) (string, error), error) {
+	return newGeoGeneralizingFunc(step.Options)
+}
+
+func (geoBuilder) BuildRow(step model.AnoMethodOption) (func(string, Row) (string, error), error) {
+	geo, err := parseGeoOptions(step.Options)
+	if err != nil {
+		return nil, err
+	}
+	return geo.rowFunc(), nil
+}
@@ -0,0 +1,75 @@
+package did
+
+import (
+	"errors"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+// value for unknown values (not in the hierarchy)
+const hierarchySuppressed = "*"
+
+// HierarchyStore provides generalization hierarchies (a map from each node value to its parent value)
+type HierarchyStore interface {
+	LoadHierarchy(name string) (map[string]string, error)
+}
+
+var gHierarchyStore HierarchyStore
+
+// SetHierarchyStore sets the store used by hierarchy-based generalization
+func SetHierarchyStore(store HierarchyStore) {
+	gHierarchyStore = store
+}
+
+func BuildHierarchyGeneralizingFunc(options model.AnoOption, level int) func(string) string {
+	return buildOrError(newHierarchyGeneralizingFunc(options, level))
+}
+
+func validateHierarchyOptions(options model.AnoOption, level int) error {
+	if options.Hierarchy == "" {
+		return newOptionError("hierarchy", "")
+	} else if level < 1 {
+		// Level 0 (or omitted level) would release the original value
+		return newOptionError("level", "generalization level (>= 1)")
+	}
+	return nil
+}
+
+// newHierarchyGeneralizingFunc maps each value to the ancestor that is `level` steps above it (level >= 1)
+func newHierarchyGeneralizingFunc(options model.AnoOption, level int) (func(string) (string, error), error) {
+	if err := validateHierarchyOptions(options, level); err != nil {
+		return nil, err
+	} else if gHierarchyStore == nil {
+		return nil, errors.New("No hierarchy store was registered")
+	}
+	parents, err := gHierarchyStore.LoadHierarchy(options.Hierarchy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generalized values (cache)
+	generalized := make(map[string]string)
+	return func(inString string) (string, error) {
+		if inString == "" {
+			return "", nil
+		} else if value, ok := generalized[inString]; ok {
+			return value, nil
+		}
+		value := inString
+		if _, ok := parents[value]; !ok {
+			value = hierarchySuppressed
+		} else {
+			// Move up the tree (stop at the root)
+			for i := 0; i < level; i++ {
+				parent, ok := parents[value]
+				if !ok || parent == "" {
+					break
+				}
+				value = parent
+			}
+		}
+		generalized[inString] = value
+		return value, nil
+	}, nil
+}
@@ -0,0 +1,58 @@
+package did
+
+import (
+	"testing"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+// testHierarchyStore provides fixed hierarchies for tests
+type testHierarchyStore map[string]map[string]string
+
+func (s testHierarchyStore) LoadHierarchy(name string) (map[string]string, error) {
+	return s[name], nil
+}
+
+func TestHierarchyLevel(t *testing.T) {
+	SetHierarchyStore(testHierarchyStore{"address": {
+		"서울시 강남구 역삼동": "서울시 강남구",
+		"서울시 강남구":     "서울시",
+		"서울시":         "",
+	}})
+	defer SetHierarchyStore(nil)
+
+	// Level is required (an omitted level must not release the original value)
+	options := map[string]model.AnoParamOption{
+		"address": {Method: "hierarchy_generalization", Options: model.AnoOption{Hierarchy: "address"}},
+	}
+	errs, ok := ValidateOptions(options).(OptionErrors)
+	if !ok || len(errs) != 1 || errs[0].Field != "level" {
+		t.Fatalf("ValidateOptions() = %v, want an error of level", errs)
+	}
+	if _, err := BuildFieldFunc(options["address"]); err == nil {
+		t.Fatal("BuildFieldFunc() without level succeeded")
+	}
+
+	cases := []struct {
+		level  int
+		input  string
+		output string
+	}{
+		{1, "서울시 강남구 역삼동", "서울시 강남구"},
+		{2, "서울시 강남구 역삼동", "서울시"},
+		// Stop at the root
+		{5, "서울시 강남구 역삼동", "서울시"},
+		// Unknown values are suppressed
+		{1, "부산시", hierarchySuppressed},
+	}
+	for _, c := range cases {
+		fn, err := newHierarchyGeneralizingFunc(model.AnoOption{Hierarchy: "address"}, c.level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output, _ := fn(c.input); output != c.output {
+			t.Errorf("level %d: generalize(%s) = %s, want %s", c.level, c.input, output, c.output)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package did
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+var errParseJson = errors.New("Invalid JSON document")
+
+// pathSegment is a segment of JSONPath (key of object, index of array or wildcard)
+type pathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJsonPath parses a subset of JSONPath ($.a.b, $['a'], $.a[0], $.a[*], $.*)
+func parseJsonPath(path string) ([]pathSegment, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, errors.New("JSONPath must start with $")
+	}
+	segments := []pathSegment{}
+	rest := path[1:]
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, errors.New("Empty key in JSONPath")
+			}
+			segments = append(segments, pathSegment{key: key, wildcard: key == "*"})
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, errors.New("Unclosed bracket in JSONPath")
+			}
+			inner := rest[1:end]
+			if inner == "*" {
+				segments = append(segments, pathSegment{wildcard: true})
+			} else if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				segments = append(segments, pathSegment{key: inner[1 : len(inner)-1]})
+			} else if index, err := strconv.Atoi(inner); err == nil && index >= 0 {
+				segments = append(segments, pathSegment{index: index, isIndex: true})
+			} else {
+				return nil, errors.New("Invalid bracket in JSONPath (" + inner + ")")
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, errors.New("Invalid JSONPath (" + path + ")")
+		}
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("JSONPath must select a field")
+	}
+	return segments, nil
+}
+
+// jsonField is a de-identification of the fields selected by a path
+type jsonField struct {
+	name   string
+	path   []pathSegment
+	fn     func(string, Row) (string, error)
+	option model.AnoParamOption
+}
+
+// apply processes the selected values of a node and returns the replaced node
+func (field jsonField) apply(node interface{}, segments []pathSegment, row Row) (interface{}, error) {
+	if len(segments) == 0 {
+		return field.process(node, row)
+	}
+	segment := segments[0]
+	switch value := node.(type) {
+	case map[string]interface{}:
+		if segment.isIndex {
+			return node, nil
+		}
+		for key, child := range value {
+			if segment.wildcard || key == segment.key {
+				replaced, err := field.apply(child, segments[1:], row)
+				if err != nil {
+					return nil, err
+				}
+				value[key] = replaced
+			}
+		}
+	case []interface{}:
+		for i, child := range value {
+			if segment.wildcard || (segment.isIndex && i == segment.index) {
+				replaced, err := field.apply(child, segments[1:], row)
+				if err != nil {
+					return nil, err
+				}
+				value[i] = replaced
+			}
+		}
+	}
+	return node, nil
+}
+
+// process de-identifies a selected value (numbers stay numbers if the result is a number)
+func (field jsonField) process(node interface{}, row Row) (interface{}, error) {
+	var inString string
+	switch value := node.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		inString = value
+	case json.Number:
+		inString = value.String()
+	case bool:
+		inString = strconv.FormatBool(value)
+	default:
+		// Object or array is processed as JSON string
+		encoded, err := marshalJson(value)
+		if err != nil {
+			return nil, err
+		}
+		inString = encoded
+	}
+
+	// Nested field is processed as a column of its own (column and path)
+	nested := row
+	nested.column = row.column + field.name
+	output, err := field.fn(inString, nested)
+	if err != nil {
+		// Suppress or abort of more nested options is passed as it is
+		if _, ok := err.(*PolicyError); ok {
+			return nil, err
+		}
+		// Process by error policy of field (suppress and abort are applied to the whole row)
+		switch field.option.OnError {
+		case "", "null":
+			row.handleError(err)
+			return nil, nil
+		case "replace":
+			row.handleError(err)
+			return field.option.Replacement, nil
+		default:
+			return nil, &PolicyError{Policy: field.option.OnError, Path: field.name, Err: err}
+		}
+	}
+	if _, isNumber := node.(json.Number); isNumber {
+		if _, err := strconv.ParseFloat(output, 64); err == nil {
+			return json.Number(output), nil
+		}
+	}
+	return output, nil
+}
+
+func marshalJson(value interface{}) (string, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+// sortedPaths returns the paths of nested options (stable order of processing)
+func sortedPaths(fields map[string]model.AnoParamOption) []string {
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func validateJsonFieldsOptions(options model.AnoOption) error {
+	if len(options.Fields) == 0 {
+		return newOptionError("fields", "")
+	}
+	for _, path := range sortedPaths(options.Fields) {
+		if _, err := parseJsonPath(path); err != nil {
+			return newOptionError("fields", err.Error())
+		}
+	}
+	// Nested options (the location of the error is the path)
+	if err := ValidateOptions(options.Fields); err != nil {
+		return newOptionError("fields", err.Error())
+	}
+	// Quantile boundaries are not computed for nested fields (boundaries are required)
+	for _, path := range sortedPaths(options.Fields) {
+		for _, step := range Steps(options.Fields[path]) {
+			if step.Method == "data_range" && step.Options.Algorithm == "quantile" && len(step.Options.Boundaries) == 0 {
+				return newOptionError("fields", "["+path+"] quantile data_range requires boundaries in nested options")
+			}
+		}
+	}
+	return nil
+}
+
+func newJsonFieldsFunc(options model.AnoOption) (func(string, Row) (string, error), error) {
+	if err := validateJsonFieldsOptions(options); err != nil {
+		return nil, err
+	}
+	fields := make([]jsonField, 0, len(options.Fields))
+	for _, path := range sortedPaths(options.Fields) {
+		segments, _ := parseJsonPath(path)
+		fn, err := BuildRowFunc(options.Fields[path])
+		if err != nil {
+			return nil, newOptionError("fields", "["+path+"] "+err.Error())
+		}
+		fields = append(fields, jsonField{name: path, path: segments, fn: fn, option: options.Fields[path]})
+	}
+
+	return func(inString string, row Row) (string, error) {
+		if inString == "" {
+			return "", nil
+		}
+		decoder := json.NewDecoder(strings.NewReader(inString))
+		decoder.UseNumber()
+		var document interface{}
+		if err := decoder.Decode(&document); err != nil {
+			return "", errParseJson
+		}
+		for _, field := range fields {
+			var err error
+			if document, err = field.apply(document, field.path, row); err != nil {
+				return "", err
+			}
+		}
+		return marshalJson(document)
+	}, nil
+}
+
+// jsonFieldsBuilder builds the de-identification of the fields in JSON document (nested options for each JSONPath)
+type jsonFieldsBuilder struct{}
+
+func (jsonFieldsBuilder) Validate(step model.AnoMethodOption) error {
+	return validateJsonFieldsOptions(step.Options)
+}
+
+func (jsonFieldsBuilder) Build(step model.AnoMethodOption) (func(string) (string, error), error) {
+	fn, err := newJsonFieldsFunc(step.Options)
+	if err != nil {
+		return nil, err
+	}
+	return func(inString string) (string, error) {
+		return fn(inString, Row{})
+	}, nil
+}
+
+func (jsonFieldsBuilder) BuildRow(step model.AnoMethodOption) (func(string, Row) (string, error), error) {
+	return newJsonFieldsFunc(step.Options)
+}
+
+func BuildJsonFieldsFunc(options model.AnoOption) func(string) string {
+	return buildOrError(jsonFieldsBuilder{}.Build(model.AnoMethodOption{Method: "json_fields", Options: options}))
+}
@@ -0,0 +1,64 @@
+package did
+
+import (
+	"testing"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+func TestJsonFieldsErrorPolicy(t *testing.T) {
+	rounding := model.AnoParamOption{Method: "rounding", Options: model.AnoOption{Algorithm: "round"}}
+	input := `{"age":"unknown","name":"kim"}`
+
+	cases := []struct {
+		onError string
+		output  string
+		policy  string
+		handled int
+	}{
+		{"", `{"age":null,"name":"kim"}`, "", 1},
+		{"replace", `{"age":"-","name":"kim"}`, "", 1},
+		// Suppress and abort are applied to the whole row
+		{"suppress", "", "suppress", 0},
+		{"abort", "", "abort", 0},
+	}
+	for _, c := range cases {
+		option := rounding
+		option.OnError = c.onError
+		option.Replacement = "-"
+		fn, err := newJsonFieldsFunc(model.AnoOption{Fields: map[string]model.AnoParamOption{"$.age": option}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		handled := 0
+		row := Row{}.WithErrorHandler(func(error) {
+			handled++
+		})
+		output, err := fn(input, row)
+		if c.policy == "" {
+			if err != nil || output != c.output {
+				t.Errorf("onError %s: output = %s, %v, want %s", c.onError, output, err, c.output)
+			}
+		} else if policyErr, ok := err.(*PolicyError); !ok || policyErr.Policy != c.policy || policyErr.Path != "$.age" {
+			t.Errorf("onError %s: error = %v, want a policy error", c.onError, err)
+		}
+		if handled != c.handled {
+			t.Errorf("onError %s: %d errors are handled, want %d", c.onError, handled, c.handled)
+		}
+	}
+
+	// Abort of more nested options is not handled by the policy of outer field
+	inner := rounding
+	inner.OnError = "abort"
+	outer := model.AnoParamOption{Method: "json_fields", Options: model.AnoOption{Fields: map[string]model.AnoParamOption{"$.age": inner}}}
+	fn, err := newJsonFieldsFunc(model.AnoOption{Fields: map[string]model.AnoParamOption{"$.profile": outer}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fn(`{"profile":{"age":"unknown"}}`, Row{}); err == nil {
+		t.Error("abort of nested options is not passed")
+	} else if policyErr, ok := err.(*PolicyError); !ok || policyErr.Policy != "abort" {
+		t.Errorf("error = %v, want a policy error", err)
+	}
+}
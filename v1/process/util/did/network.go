@@ -0,0 +1,115 @@
+package did
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"net"
+	"strconv"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+var errParseAddress = errors.New("Invalid network address format")
+
+func parsePrefixLength(value string, field string, defaultLength int, maxLength int) (int, error) {
+	if value == "" {
+		return defaultLength, nil
+	}
+	length, err := strconv.ParseInt(value, 10, 0)
+	if err != nil || length < 0 || int(length) > maxLength {
+		return 0, newOptionError(field, "0 ~ "+strconv.Itoa(maxLength))
+	}
+	return int(length), nil
+}
+
+// generalizeAddress keeps the prefix bits and replaces the host bits (zero or the bits of fill)
+func generalizeAddress(addr []byte, prefix int, fill []byte) []byte {
+	result := make([]byte, len(addr))
+	copy(result, addr)
+	for i := range result {
+		bit := i * 8
+		if bit+8 <= prefix {
+			continue
+		}
+		hostMask := byte(0xFF)
+		if bit < prefix {
+			hostMask = 0xFF >> uint(prefix-bit)
+		}
+		result[i] &^= hostMask
+		if fill != nil {
+			result[i] |= fill[i%len(fill)] & hostMask
+		}
+	}
+	return result
+}
+
+func BuildIpGeneralizingFunc(options model.AnoOption) func(string) string {
+	return buildOrError(newIpGeneralizingFunc(options))
+}
+
+func newIpGeneralizingFunc(options model.AnoOption) (func(string) (string, error), error) {
+	prefix4, err := parsePrefixLength(options.Ipv4Prefix, "ipv4Prefix", 24, 32)
+	if err != nil {
+		return nil, err
+	}
+	prefix6, err := parsePrefixLength(options.Ipv6Prefix, "ipv6Prefix", 48, 128)
+	if err != nil {
+		return nil, err
+	}
+	prefixMac, err := parsePrefixLength(options.MacPrefix, "macPrefix", 24, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set process for host part (zero or hmac)
+	var mac hash.Hash
+	switch options.Algorithm {
+	case "zero", "":
+	case "hmac":
+		switch options.Digest {
+		case "sha256", "":
+			mac = hmac.New(sha256.New, []byte(options.Key))
+		case "md5":
+			mac = hmac.New(md5.New, []byte(options.Key))
+		default:
+			return nil, newOptionError("digest", "unknown digest")
+		}
+	default:
+		return nil, newOptionError("algorithm", "unknown IpGeneralization algorithm")
+	}
+	hostFill := func(addr []byte) []byte {
+		if mac == nil {
+			return nil
+		}
+		mac.Write(addr)
+		defer mac.Reset()
+		return mac.Sum(nil)
+	}
+	// Append prefix length (CIDR notation) only if the host part is zero
+	cidr := options.Format == "cidr" && mac == nil
+
+	return func(inString string) (string, error) {
+		if inString == "" {
+			return "", nil
+		}
+		if ip := net.ParseIP(inString); ip != nil {
+			prefix := prefix6
+			if v4 := ip.To4(); v4 != nil {
+				ip, prefix = v4, prefix4
+			}
+			output := net.IP(generalizeAddress(ip, prefix, hostFill(ip))).String()
+			if cidr {
+				output += "/" + strconv.Itoa(prefix)
+			}
+			return output, nil
+		}
+		if hw, err := net.ParseMAC(inString); err == nil {
+			return net.HardwareAddr(generalizeAddress(hw, prefixMac, hostFill(hw))).String(), nil
+		}
+		return "", errParseAddress
+	}, nil
+}
@@ -0,0 +1,203 @@
+package did
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	mrand "math/rand"
+	"strconv"
+	"sync"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+// noiseSource generates random numbers for noise mechanisms
+type noiseSource interface {
+	Float64() float64
+	NormFloat64() float64
+}
+
+// newNoiseSource creates a random source seeded from crypto random (for options without seed)
+func newNoiseSource() (noiseSource, error) {
+	var buffer [8]byte
+	if _, err := rand.Read(buffer[:]); err != nil {
+		return nil, err
+	}
+	return mrand.New(mrand.NewSource(int64(binary.LittleEndian.Uint64(buffer[:])))), nil
+}
+
+// rowNoiseSource is a random source derived from the seed, the column, the step and the sequence number of row (splitmix64)
+// The noise of each row is reproducible regardless of which go-routine processes the row, and independent for each column
+type rowNoiseSource struct {
+	state uint64
+}
+
+func newRowNoiseSource(seed int64, row Row) *rowNoiseSource {
+	seq, _ := row.Sequence()
+	hash := fnv.New64a()
+	hash.Write([]byte(row.column))
+	source := &rowNoiseSource{state: uint64(seed)}
+	for _, value := range []uint64{hash.Sum64(), uint64(row.step), uint64(seq)} {
+		source.state ^= value
+		source.state = source.next()
+	}
+	return source
+}
+
+func (s *rowNoiseSource) next() uint64 {
+	s.state += 0x9E3779B97F4A7C15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+func (s *rowNoiseSource) Float64() float64 {
+	return float64(s.next()>>11) / (1 << 53)
+}
+
+// NormFloat64 returns a standard normal random number (Box-Muller transform)
+func (s *rowNoiseSource) NormFloat64() float64 {
+	u1 := openUnit(s)
+	u2 := s.Float64()
+	return math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*u2)
+}
+
+// openUnit returns a random number in the open interval (0, 1) (the logarithm of it is finite)
+func openUnit(source noiseSource) float64 {
+	for {
+		if u := source.Float64(); u > 0 {
+			return u
+		}
+	}
+}
+
+func BuildNoiseAddingFunc(options model.AnoOption) func(string) string {
+	return buildOrError(newNoiseAddingFunc(options))
+}
+
+// newNoiseAddingFunc creates a function without row context (with seed, rows are numbered in order of calls)
+func newNoiseAddingFunc(options model.AnoOption) (func(string) (string, error), error) {
+	fn, err := newNoiseAddingRowFunc(options)
+	if err != nil {
+		return nil, err
+	}
+	var mutex sync.Mutex
+	var count int64
+	return func(inString string) (string, error) {
+		mutex.Lock()
+		seq := count
+		count++
+		mutex.Unlock()
+		return fn(inString, Row{seq: seq, sequenced: true})
+	}, nil
+}
+
+func newNoiseAddingRowFunc(options model.AnoOption) (func(string, Row) (string, error), error) {
+	epsilon, err := strconv.ParseFloat(options.Epsilon, 64)
+	if err != nil || epsilon <= 0 {
+		return nil, newOptionError("epsilon", "")
+	}
+
+	// Clamping bounds (optional)
+	lowBound, upBound := math.Inf(-1), math.Inf(1)
+	if options.Lower != "" {
+		if lowBound, err = strconv.ParseFloat(options.Lower, 64); err != nil {
+			return nil, newOptionError("lower", "")
+		}
+	}
+	if options.Upper != "" {
+		if upBound, err = strconv.ParseFloat(options.Upper, 64); err != nil || upBound < lowBound {
+			return nil, newOptionError("upper", "")
+		}
+	}
+
+	// Sensitivity (default: the width of clamping bounds)
+	var sensitivity float64
+	if options.Sensitivity != "" {
+		sensitivity, err = strconv.ParseFloat(options.Sensitivity, 64)
+	} else if options.Lower != "" && options.Upper != "" {
+		sensitivity = upBound - lowBound
+	} else {
+		err = strconv.ErrSyntax
+	}
+	if err != nil || sensitivity <= 0 {
+		return nil, newOptionError("sensitivity", "")
+	}
+
+	// Random source (reproducible noise for each row if options.Seed is set)
+	var seed int64
+	var shared noiseSource
+	if options.Seed != "" {
+		if seed, err = strconv.ParseInt(options.Seed, 10, 64); err != nil {
+			return nil, newOptionError("seed", "")
+		}
+	} else if shared, err = newNoiseSource(); err != nil {
+		return nil, err
+	}
+	sourceOf := func(row Row) noiseSource {
+		if shared != nil {
+			return shared
+		}
+		return newRowNoiseSource(seed, row)
+	}
+
+	// Build noise generator by mechanism
+	var noise func(source noiseSource) float64
+	switch options.Algorithm {
+	case "laplace":
+		scale := sensitivity / epsilon
+		noise = func(source noiseSource) float64 {
+			u := openUnit(source) - 0.5
+			if u < 0 {
+				return scale * math.Log(1+2*u)
+			}
+			return -scale * math.Log(1-2*u)
+		}
+	case "gaussian":
+		delta, err := strconv.ParseFloat(options.Delta, 64)
+		if err != nil || delta <= 0 || delta >= 1 {
+			return nil, newOptionError("delta", "")
+		}
+		sigma := sensitivity * math.Sqrt(2*math.Log(1.25/delta)) / epsilon
+		noise = func(source noiseSource) float64 {
+			return source.NormFloat64() * sigma
+		}
+	default:
+		return nil, newOptionError("algorithm", "unknown NoiseAddition algorithm")
+	}
+
+	// Decimal places of output (position)
+	precision := 0
+	if options.Position > 0 {
+		precision = options.Position
+	}
+	return func(inString string, row Row) (string, error) {
+		if inString == "" {
+			return "", nil
+		}
+		if value, err := strconv.ParseFloat(inString, 64); err == nil {
+			value = math.Min(math.Max(value, lowBound), upBound)
+			return strconv.FormatFloat(value+noise(sourceOf(row)), 'f', precision, 64), nil
+		}
+		return "", errParseFloat
+	}, nil
+}
+
+// noiseBuilder builds the noise addition (the noise of each row is derived from the sequence number of row with seed)
+type noiseBuilder struct{}
+
+func (noiseBuilder) Validate(step model.AnoMethodOption) error {
+	_, err := newNoiseAddingRowFunc(step.Options)
+	return err
+}
+
+func (noiseBuilder) Build(step model.AnoMethodOption) (func(string) (string, error), error) {
+	return newNoiseAddingFunc(step.Options)
+}
+
+func (noiseBuilder) BuildRow(step model.AnoMethodOption) (func(string, Row) (string, error), error) {
+	return newNoiseAddingRowFunc(step.Options)
+}
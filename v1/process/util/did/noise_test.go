@@ -0,0 +1,102 @@
+package did
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+// noiseSamples adds noise to value for rows 0 ~ n-1 and returns the noise
+func noiseSamples(t *testing.T, options model.AnoOption, value float64, n int) []float64 {
+	fn, err := newNoiseAddingRowFunc(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout := NewRowLayout([]string{"value"})
+	samples := make([]float64, n)
+	for i := range samples {
+		output, err := fn(strconv.FormatFloat(value, 'f', -1, 64), layout.Row(int64(i), nil).WithColumn("value"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := strconv.ParseFloat(output, 64)
+		if err != nil || math.IsInf(result, 0) || math.IsNaN(result) {
+			t.Fatalf("noise output = %s", output)
+		}
+		samples[i] = result - value
+	}
+	return samples
+}
+
+func TestNoiseSeed(t *testing.T) {
+	options := model.AnoOption{Algorithm: "laplace", Epsilon: "1", Sensitivity: "10", Seed: "42", Position: 6}
+	fn, err := newNoiseAddingRowFunc(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout := NewRowLayout([]string{"a", "b"})
+	row := layout.Row(7, nil)
+
+	// Same seed and sequence number, same noise (regardless of the order of processing)
+	first, _ := fn("100", row.WithColumn("a"))
+	fn("100", layout.Row(8, nil).WithColumn("a"))
+	second, _ := fn("100", row.WithColumn("a"))
+	if first != second {
+		t.Errorf("noise of same row = %s, %s", first, second)
+	}
+	// Independent noise for each row and column with same seed
+	if other, _ := fn("100", layout.Row(8, nil).WithColumn("a")); other == first {
+		t.Errorf("noise of other row = %s, same as %s", other, first)
+	}
+	if other, _ := fn("100", row.WithColumn("b")); other == first {
+		t.Errorf("noise of other column = %s, same as %s", other, first)
+	}
+}
+
+func TestNoiseClamping(t *testing.T) {
+	// Negligible noise (the output is the clamped value)
+	options := model.AnoOption{Algorithm: "laplace", Epsilon: "1000000", Sensitivity: "1", Lower: "0", Upper: "100", Seed: "1"}
+	fn, err := newNoiseAddingRowFunc(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{"-50": "0", "50": "50", "1000": "100"}
+	for input, output := range cases {
+		if result, err := fn(input, Row{}); err != nil || result != output {
+			t.Errorf("noise(%s) = %s, %v, want %s", input, result, err, output)
+		}
+	}
+	// Sensitivity is the width of bounds by default
+	if _, err := newNoiseAddingRowFunc(model.AnoOption{Algorithm: "laplace", Epsilon: "1", Lower: "0", Upper: "100"}); err != nil {
+		t.Errorf("default sensitivity: %v", err)
+	}
+}
+
+func TestNoiseScale(t *testing.T) {
+	const n = 20000
+	// Laplace: mean absolute deviation is the scale (sensitivity / epsilon)
+	samples := noiseSamples(t, model.AnoOption{Algorithm: "laplace", Epsilon: "0.5", Sensitivity: "2", Seed: "7", Position: 6}, 50, n)
+	deviation := 0.0
+	for _, sample := range samples {
+		deviation += math.Abs(sample)
+	}
+	if scale := deviation / n; math.Abs(scale-4) > 0.2 {
+		t.Errorf("laplace scale = %f, want 4", scale)
+	}
+
+	// Gaussian: standard deviation is sensitivity * sqrt(2 * ln(1.25 / delta)) / epsilon
+	samples = noiseSamples(t, model.AnoOption{Algorithm: "gaussian", Epsilon: "1", Delta: "0.00001", Sensitivity: "1", Seed: "7", Position: 6}, 50, n)
+	sum, squares := 0.0, 0.0
+	for _, sample := range samples {
+		sum += sample
+		squares += sample * sample
+	}
+	sigma := math.Sqrt(2*math.Log(1.25/0.00001)) / 1
+	mean := sum / n
+	if deviation := math.Sqrt(squares/n - mean*mean); math.Abs(deviation-sigma)/sigma > 0.05 || math.Abs(mean) > 0.1*sigma {
+		t.Errorf("gaussian mean, deviation = %f, %f, want 0, %f", mean, deviation, sigma)
+	}
+}
@@ -0,0 +1,236 @@
+package did
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"unicode"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+// piiPattern is a PII pattern to detect in free text (validate is optional to reduce false positives)
+type piiPattern struct {
+	regex    *regexp.Regexp
+	validate func(string) bool
+	find     func(string) [][]int // custom finder instead of regex and validate (optional)
+}
+
+// locate returns the locations of PII in text (in order, not overlapped)
+func (p piiPattern) locate(text string) [][]int {
+	if p.find != nil {
+		return p.find(text)
+	}
+	locations := p.regex.FindAllStringIndex(text, -1)
+	if p.validate == nil {
+		return locations
+	}
+	valid := locations[:0]
+	for _, loc := range locations {
+		if p.validate(text[loc[0]:loc[1]]) {
+			valid = append(valid, loc)
+		}
+	}
+	return valid
+}
+
+// built-in PII patterns (applied in this order when no rules are given)
+var piiPatternOrder = []string{"email", "card", "rrn", "phone", "passport", "ip"}
+var piiPatterns = map[string]piiPattern{
+	// e-mail address
+	"email": {regex: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	// credit card number (13~16 digits, or 4-6-5 digits) with luhn check
+	"card": {regex: regexp.MustCompile(`\b(?:\d{4}[- ]?\d{4}[- ]?\d{4}[- ]?\d{1,4}|\d{4}[- ]?\d{6}[- ]?\d{5})\b`), validate: validateLuhn},
+	// korean resident registration number
+	"rrn": {regex: regexp.MustCompile(`\b\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])[- ]?[1-8]\d{6}\b`)},
+	// korean phone number (mobile, landline, internet phone)
+	"phone": {regex: regexp.MustCompile(`(?:\+82[- ]?|\b0)(?:1[016789]|2|[3-6][1-5]|70)[- )]?\d{3,4}[- ]?\d{4}\b`)},
+	// korean passport number
+	"passport": {regex: regexp.MustCompile(`\b[MSRGD](?:\d{8}|\d{3}[A-Z]\d{4})\b`)},
+	// IPv4, IPv6 address
+	"ip": {find: findIp},
+}
+
+var (
+	ipv4Regex = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	// a run of characters that can be a part of IPv6 address (with zone), IPv6 address must be a whole token
+	ipv6TokenRegex = regexp.MustCompile(`[0-9A-Za-z_.:%]+`)
+)
+
+func validateLuhn(match string) bool {
+	sum := 0
+	double := false
+	for i := len(match) - 1; i >= 0; i-- {
+		if match[i] < '0' || match[i] > '9' {
+			continue
+		}
+		digit := int(match[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func validateIp(match string) bool {
+	return net.ParseIP(match) != nil
+}
+
+// validateIpv6 verifies a token as IPv6 address (at least 2 non-empty groups and a digit to exclude words like "cafe::beef")
+func validateIpv6(token string) bool {
+	address := token
+	if i := strings.IndexByte(address, '%'); i >= 0 {
+		address = address[:i]
+	}
+	if !strings.Contains(address, ":") || net.ParseIP(address) == nil {
+		return false
+	}
+	groups := 0
+	for _, group := range strings.Split(address, ":") {
+		if group != "" {
+			groups++
+		}
+	}
+	return groups >= 2 && strings.ContainsAny(address, "0123456789")
+}
+
+// findIp finds IPv6 addresses (whole tokens) and IPv4 addresses (not a part of IPv6 address)
+func findIp(text string) [][]int {
+	ipv6 := [][]int{}
+	for _, loc := range ipv6TokenRegex.FindAllStringIndex(text, -1) {
+		// Trailing dots are punctuation
+		end := loc[1]
+		for end > loc[0] && text[end-1] == '.' {
+			end--
+		}
+		if validateIpv6(text[loc[0]:end]) {
+			ipv6 = append(ipv6, []int{loc[0], end})
+		}
+	}
+
+	// Merge in order
+	locations := make([][]int, 0, len(ipv6))
+	next := 0
+	for _, loc := range ipv4Regex.FindAllStringIndex(text, -1) {
+		for next < len(ipv6) && ipv6[next][1] <= loc[0] {
+			locations = append(locations, ipv6[next])
+			next++
+		}
+		if next < len(ipv6) && ipv6[next][0] < loc[1] {
+			continue
+		} else if validateIp(text[loc[0]:loc[1]]) {
+			locations = append(locations, loc)
+		}
+	}
+	return append(locations, ipv6[next:]...)
+}
+
+// replaceLocations replaces the text at each location (in order, not overlapped)
+func replaceLocations(text string, locations [][]int, replace func(string) string) string {
+	if len(locations) == 0 {
+		return text
+	}
+	var buffer strings.Builder
+	last := 0
+	for _, loc := range locations {
+		buffer.WriteString(text[last:loc[0]])
+		buffer.WriteString(replace(text[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	buffer.WriteString(text[last:])
+	return buffer.String()
+}
+
+// buildPiiReplacer builds a replacement function by policy
+func buildPiiReplacer(rule model.PiiRule, name string, maskChar rune) (func(string) string, bool) {
+	switch rule.Policy {
+	case "mask", "":
+		// Mask letters and digits (keep separators)
+		return func(match string) string {
+			return strings.Map(func(char rune) rune {
+				if unicode.IsLetter(char) || unicode.IsDigit(char) {
+					return maskChar
+				}
+				return char
+			}, match)
+		}, true
+	case "redact":
+		replacement := rule.Replacement
+		if replacement == "" {
+			replacement = "[" + strings.ToUpper(name) + "]"
+		}
+		return func(match string) string {
+			return replacement
+		}, true
+	case "remove":
+		return func(match string) string {
+			return ""
+		}, true
+	default:
+		return nil, false
+	}
+}
+
+func BuildPiiReducingFunc(options model.AnoOption) func(string) string {
+	return buildOrError(newPiiReducingFunc(options))
+}
+
+func newPiiReducingFunc(options model.AnoOption) (func(string) (string, error), error) {
+	// Fixed-position masking (compatibility for options without PII rules)
+	if len(options.Pii) == 0 && (options.Fore != "" || options.Aft != "" || options.Percent != "") {
+		return newMaskingFunc(options)
+	}
+
+	maskChar := '*'
+	if runes := []rune(options.MaskChar); len(runes) > 0 {
+		maskChar = runes[0]
+	}
+	// Set rules (default: all built-in patterns)
+	rules := options.Pii
+	if len(rules) == 0 {
+		for _, name := range piiPatternOrder {
+			rules = append(rules, model.PiiRule{Pattern: name})
+		}
+	}
+
+	// Build a list of detectors
+	type detector struct {
+		pattern piiPattern
+		replace func(string) string
+	}
+	detectors := make([]detector, 0, len(rules))
+	for _, rule := range rules {
+		var pattern piiPattern
+		if rule.Regex != "" {
+			// Custom pattern
+			re, err := regexp.Compile(rule.Regex)
+			if err != nil {
+				return nil, newOptionError("regex", "")
+			}
+			pattern = piiPattern{regex: re}
+		} else if builtIn, ok := piiPatterns[rule.Pattern]; ok {
+			pattern = builtIn
+		} else {
+			return nil, newOptionError("pii", "unknown PII pattern")
+		}
+		replace, ok := buildPiiReplacer(rule, rule.Pattern, maskChar)
+		if !ok {
+			return nil, newOptionError("pii", "unknown PII policy")
+		}
+		detectors = append(detectors, detector{pattern: pattern, replace: replace})
+	}
+
+	return func(inString string) (string, error) {
+		output := inString
+		for _, d := range detectors {
+			output = replaceLocations(output, d.pattern.locate(output), d.replace)
+		}
+		return output, nil
+	}, nil
+}
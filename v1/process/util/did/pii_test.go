@@ -0,0 +1,47 @@
+package did
+
+import (
+	"testing"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+func TestPiiIp(t *testing.T) {
+	mask, err := newPiiReducingFunc(model.AnoOption{Pii: []model.PiiRule{{Pattern: "ip"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	redact, err := newPiiReducingFunc(model.AnoOption{Pii: []model.PiiRule{{Pattern: "ip", Policy: "redact"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		input    string
+		masked   string
+		redacted string
+	}{
+		// Addresses
+		{"from 192.168.0.1 to 10.0.0.254", "from ***.***.*.* to **.*.*.***", "from [IP] to [IP]"},
+		{"host 2001:db8::1 is down", "host ****:***::* is down", "host [IP] is down"},
+		{"link fe80::1%eth0.", "link ****::*%****.", "link [IP]."},
+		{"mapped ::ffff:192.0.2.1", "mapped ::****:***.*.*.*", "mapped [IP]"},
+		{"host:10.0.0.1", "host:**.*.*.*", "host:[IP]"},
+		// False positives
+		{"use std::vector and Note:: cafe::beef", "use std::vector and Note:: cafe::beef", "use std::vector and Note:: cafe::beef"},
+		{"see Answer:: below", "see Answer:: below", "see Answer:: below"},
+		{"at 10:30:45, ratio 3:1", "at 10:30:45, ratio 3:1", "at 10:30:45, ratio 3:1"},
+		{"mac 00:1a:2b:3c:4d:5e", "mac 00:1a:2b:3c:4d:5e", "mac 00:1a:2b:3c:4d:5e"},
+		{"version 999.1.1.1", "version 999.1.1.1", "version 999.1.1.1"},
+		{"x::1y and a_fe80::1", "x::1y and a_fe80::1", "x::1y and a_fe80::1"},
+	}
+	for _, c := range cases {
+		if output, _ := mask(c.input); output != c.masked {
+			t.Errorf("mask(%s) = %s, want %s", c.input, output, c.masked)
+		}
+		if output, _ := redact(c.input); output != c.redacted {
+			t.Errorf("redact(%s) = %s, want %s", c.input, output, c.redacted)
+		}
+	}
+}
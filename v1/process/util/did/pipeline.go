@@ -0,0 +1,45 @@
+package did
+
+import (
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+// Steps returns the ordered de-identification steps of a column (single method options are a pipeline with one step)
+func Steps(option model.AnoParamOption) []model.AnoMethodOption {
+	if len(option.Pipeline) > 0 {
+		return option.Pipeline
+	}
+	return []model.AnoMethodOption{{Method: option.Method, Options: option.Options, Level: option.Level}}
+}
+
+// Compose composes functions into one function (applied in order, stop at the first error)
+func Compose(funcs ...func(string) (string, error)) func(string) (string, error) {
+	if len(funcs) == 1 {
+		return funcs[0]
+	}
+	return func(inString string) (string, error) {
+		output := inString
+		for _, fn := range funcs {
+			var err error
+			if output, err = fn(output); err != nil {
+				return "", err
+			}
+		}
+		return output, nil
+	}
+}
+
+// BuildFieldFunc builds a function for a column (compose the functions for each step)
+func BuildFieldFunc(option model.AnoParamOption) (func(string) (string, error), error) {
+	steps := Steps(option)
+	funcs := make([](func(string) (string, error)), 0, len(steps))
+	for _, step := range steps {
+		fn, err := BuildStepFunc(step)
+		if err != nil {
+			return nil, err
+		}
+		funcs = append(funcs, fn)
+	}
+	return Compose(funcs...), nil
+}
@@ -0,0 +1,150 @@
+package did
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+// Builder builds a function to de-identify each value of a column
+type Builder interface {
+	// Validate verifies the options of a step (without loading external resources)
+	Validate(step model.AnoMethodOption) error
+	// Build returns a function to process each value
+	Build(step model.AnoMethodOption) (func(string) (string, error), error)
+}
+
+// BuilderFunc adapts a function to Builder (options are validated by building the function)
+type BuilderFunc func(step model.AnoMethodOption) (func(string) (string, error), error)
+
+func (fn BuilderFunc) Validate(step model.AnoMethodOption) error {
+	_, err := fn(step)
+	return err
+}
+
+func (fn BuilderFunc) Build(step model.AnoMethodOption) (func(string) (string, error), error) {
+	return fn(step)
+}
+
+// resourceBuilder is a builder that needs external resources (key store, token vault, ...) to build
+type resourceBuilder struct {
+	validate func(step model.AnoMethodOption) error
+	build    BuilderFunc
+}
+
+func (b resourceBuilder) Validate(step model.AnoMethodOption) error {
+	return b.validate(step)
+}
+
+func (b resourceBuilder) Build(step model.AnoMethodOption) (func(string) (string, error), error) {
+	return b.build(step)
+}
+
+// optionsBuilder creates a builder from a function that only uses options
+func optionsBuilder(fn func(model.AnoOption) (func(string) (string, error), error)) BuilderFunc {
+	return func(step model.AnoMethodOption) (func(string) (string, error), error) {
+		return fn(step.Options)
+	}
+}
+
+var (
+	gRegistryLock sync.RWMutex
+	gRegistry     = map[string]Builder{
+		"encryption": resourceBuilder{
+			validate: func(step model.AnoMethodOption) error {
+				return validateEncryptingOptions(step.Options)
+			},
+			build: optionsBuilder(newEncryptingFunc),
+		},
+		"tokenization": tokenBuilder{},
+		"hierarchy_generalization": resourceBuilder{
+			validate: func(step model.AnoMethodOption) error {
+				return validateHierarchyOptions(step.Options, step.Level)
+			},
+			build: func(step model.AnoMethodOption) (func(string) (string, error), error) {
+				return newHierarchyGeneralizingFunc(step.Options, step.Level)
+			},
+		},
+		"substitution": resourceBuilder{
+			validate: func(step model.AnoMethodOption) error {
+				return validateSubstitutingOptions(step.Options)
+			},
+			build: optionsBuilder(newSubstitutingFunc),
+		},
+		"rounding": optionsBuilder(newRoundingFunc),
+		"data_range": resourceBuilder{
+			validate: func(step model.AnoMethodOption) error {
+				return validateRangingOptions(step.Options)
+			},
+			build: optionsBuilder(newRangingFunc),
+		},
+		"top_bottom_coding":    optionsBuilder(newTopBottomCodingFunc),
+		"date_generalization":  optionsBuilder(newDateGeneralizingFunc),
+		"noise_addition":       noiseBuilder{},
+		"ip_generalization":    optionsBuilder(newIpGeneralizingFunc),
+		"geo_generalization":   geoBuilder{},
+		"date_shift":           dateShiftBuilder{},
+		"json_fields":          jsonFieldsBuilder{},
+		"attribute_derivation": optionsBuilder(newDerivingFunc),
+		"blank_impute":         optionsBuilder(newMaskingFunc),
+		"pii_reduction":        optionsBuilder(newPiiReducingFunc),
+		"non": BuilderFunc(func(step model.AnoMethodOption) (func(string) (string, error), error) {
+			return func(inString string) (string, error) {
+				return inString, nil
+			}, nil
+		}),
+	}
+)
+
+// Register adds a de-identification method (the name of built-in or registered method can't be used)
+func Register(name string, builder Builder) error {
+	if name == "" {
+		return errors.New("Invalid method name (can not be blank)")
+	} else if builder == nil {
+		return errors.New("Invalid builder (can not be nil)")
+	}
+
+	gRegistryLock.Lock()
+	defer gRegistryLock.Unlock()
+	if _, exists := gRegistry[name]; exists {
+		return errors.New("Method that already exist (" + name + ")")
+	}
+	gRegistry[name] = builder
+	return nil
+}
+
+// Lookup finds a builder by method name
+func Lookup(name string) (Builder, bool) {
+	gRegistryLock.RLock()
+	defer gRegistryLock.RUnlock()
+	builder, exists := gRegistry[name]
+	return builder, exists
+}
+
+// Methods returns a list of registered method names
+func Methods() []string {
+	gRegistryLock.RLock()
+	defer gRegistryLock.RUnlock()
+	names := make([]string, 0, len(gRegistry))
+	for name := range gRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuildStepFunc builds a function for a step of pipeline
+func BuildStepFunc(step model.AnoMethodOption) (func(string) (string, error), error) {
+	builder, exists := Lookup(step.Method)
+	if !exists {
+		return nil, &OptionError{Method: step.Method, Field: "method", Reason: "unknown method"}
+	}
+	fn, err := builder.Build(step)
+	if optionErr, ok := err.(*OptionError); ok {
+		optionErr.Method = step.Method
+	}
+	return fn, err
+}
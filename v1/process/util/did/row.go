@@ -0,0 +1,149 @@
+package did
+
+import (
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+// RowLayout maps the column names of query result to the index of row values
+type RowLayout map[string]int
+
+func NewRowLayout(columns []string) RowLayout {
+	layout := make(RowLayout, len(columns))
+	for i, column := range columns {
+		layout[column] = i
+	}
+	return layout
+}
+
+// Row returns the row context for the values of a query result row (seq is the sequence number of query result)
+func (l RowLayout) Row(seq int64, values []string) Row {
+	return Row{layout: l, values: values, seq: seq, sequenced: true}
+}
+
+// Row provides the values of the other columns in the same row (original values before de-identification)
+type Row struct {
+	layout    RowLayout
+	values    []string
+	keyColumn string
+	column    string
+	step      int
+	seq       int64
+	sequenced bool
+	readOnly  bool
+	onError   func(error)
+}
+
+// Value returns the value of a column in the row
+func (r Row) Value(column string) (string, bool) {
+	index, exists := r.layout[column]
+	if !exists || index >= len(r.values) {
+		return "", false
+	}
+	return r.values[index], true
+}
+
+// Sequence returns the sequence number of the row (false without row context)
+func (r Row) Sequence() (int64, bool) {
+	return r.seq, r.sequenced
+}
+
+// WithColumn returns the row for the column being processed (e.g. to derive independent noise for each column)
+func (r Row) WithColumn(column string) Row {
+	r.column = column
+	return r
+}
+
+// ReadOnly returns the row processed without writing to external stores (e.g. risk analysis does not issue tokens)
+func (r Row) ReadOnly() Row {
+	r.readOnly = true
+	return r
+}
+
+// WithErrorHandler returns the row with a handler for errors handled inside a method (e.g. nested options of json_fields)
+func (r Row) WithErrorHandler(handler func(error)) Row {
+	r.onError = handler
+	return r
+}
+
+// handleError reports an error that was handled by the error policy of nested options (the value is still processed)
+func (r Row) handleError(err error) {
+	if r.onError != nil {
+		r.onError(err)
+	}
+}
+
+// Subject returns the value of the subject key column (KeyColumn of AnoParamOption)
+func (r Row) Subject() (string, bool) {
+	if r.keyColumn == "" {
+		return "", false
+	}
+	return r.Value(r.keyColumn)
+}
+
+// RowBuilder is a builder for methods that refer to the other columns in the same row
+type RowBuilder interface {
+	Builder
+	// BuildRow returns a function to process each value with the row context
+	BuildRow(step model.AnoMethodOption) (func(string, Row) (string, error), error)
+}
+
+// subjectBuilder is a row builder for methods that need the subject key column
+type subjectBuilder interface {
+	RowBuilder
+	requiresSubject()
+}
+
+// buildStepRowFunc builds a function with the row context for a step of pipeline
+func buildStepRowFunc(step model.AnoMethodOption) (func(string, Row) (string, error), error) {
+	builder, exists := Lookup(step.Method)
+	if !exists {
+		return nil, &OptionError{Method: step.Method, Field: "method", Reason: "unknown method"}
+	}
+
+	var fn func(string, Row) (string, error)
+	var err error
+	if rowBuilder, ok := builder.(RowBuilder); ok {
+		fn, err = rowBuilder.BuildRow(step)
+	} else {
+		var valueFn func(string) (string, error)
+		if valueFn, err = builder.Build(step); err == nil {
+			fn = func(inString string, row Row) (string, error) {
+				return valueFn(inString)
+			}
+		}
+	}
+	if optionErr, ok := err.(*OptionError); ok {
+		optionErr.Method = step.Method
+	}
+	return fn, err
+}
+
+// BuildRowFunc builds a function with the row context for a column (compose the functions for each step)
+func BuildRowFunc(option model.AnoParamOption) (func(string, Row) (string, error), error) {
+	steps := Steps(option)
+	funcs := make([](func(string, Row) (string, error)), 0, len(steps))
+	for _, step := range steps {
+		fn, err := buildStepRowFunc(step)
+		if err != nil {
+			return nil, err
+		}
+		funcs = append(funcs, fn)
+	}
+	keyColumn := option.KeyColumn
+	if len(funcs) == 1 && keyColumn == "" {
+		return funcs[0], nil
+	}
+	return func(inString string, row Row) (string, error) {
+		row.keyColumn = keyColumn
+		output := inString
+		for i, fn := range funcs {
+			var err error
+			row.step = i
+			if output, err = fn(output, row); err != nil {
+				return "", err
+			}
+		}
+		return output, nil
+	}, nil
+}
@@ -0,0 +1,91 @@
+package did
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"time"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+var errMissingSubject = errors.New("Subject key of the row is blank")
+
+type dateShiftOptions struct {
+	key    []byte
+	window int64 // maximum offset (days)
+}
+
+func parseDateShiftOptions(options model.AnoOption) (dateShiftOptions, error) {
+	shift := dateShiftOptions{key: []byte(options.Key)}
+	if options.Key == "" {
+		return shift, newOptionError("key", "")
+	}
+	window, err := strconv.ParseInt(options.Window, 10, 64)
+	if err != nil || window < 1 {
+		return shift, newOptionError("window", "number of days (>= 1)")
+	}
+	shift.window = window
+	return shift, nil
+}
+
+// rowFunc shifts dates by the offset derived from hmac of the subject key (-window ~ +window days)
+func (shift dateShiftOptions) rowFunc() func(string, Row) (string, error) {
+	mac := hmac.New(sha256.New, shift.key)
+	// Offsets (cache by subject)
+	offsets := make(map[string]int)
+	return func(inString string, row Row) (string, error) {
+		if inString == "" {
+			return "", nil
+		}
+		subject, exists := row.Subject()
+		if !exists {
+			return "", errMissingColumn
+		} else if subject == "" {
+			return "", errMissingSubject
+		}
+
+		offset, ok := offsets[subject]
+		if !ok {
+			mac.Write([]byte(subject))
+			offset = int(binary.BigEndian.Uint64(mac.Sum(nil)[:8])%uint64(2*shift.window+1)) - int(shift.window)
+			mac.Reset()
+			offsets[subject] = offset
+		}
+
+		for _, layout := range dateLayouts {
+			if value, err := time.Parse(layout, inString); err == nil {
+				return value.AddDate(0, 0, offset).Format(layout), nil
+			}
+		}
+		return "", errParseTime
+	}
+}
+
+// dateShiftBuilder builds the date shifting (the subject key column is required)
+type dateShiftBuilder struct{}
+
+func (dateShiftBuilder) Validate(step model.AnoMethodOption) error {
+	_, err := parseDateShiftOptions(step.Options)
+	return err
+}
+
+func (dateShiftBuilder) Build(step model.AnoMethodOption) (func(string) (string, error), error) {
+	if _, err := parseDateShiftOptions(step.Options); err != nil {
+		return nil, err
+	}
+	return nil, newOptionError("keyColumn", "date shift can only be processed with row context")
+}
+
+func (dateShiftBuilder) BuildRow(step model.AnoMethodOption) (func(string, Row) (string, error), error) {
+	shift, err := parseDateShiftOptions(step.Options)
+	if err != nil {
+		return nil, err
+	}
+	return shift.rowFunc(), nil
+}
+
+func (dateShiftBuilder) requiresSubject() {}
@@ -0,0 +1,132 @@
+package did
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"strconv"
+	"strings"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+// DictionaryStore provides user-supplied dictionaries for substitution
+type DictionaryStore interface {
+	LoadDictionary(name string) ([]string, error)
+}
+
+var gDictionaryStore DictionaryStore
+
+// SetDictionaryStore sets the store used by substitution
+func SetDictionaryStore(store DictionaryStore) {
+	gDictionaryStore = store
+}
+
+// Bundled dictionaries (fake values are composed of the parts selected by each part of digest)
+var (
+	familyNames   = []string{"김", "이", "박", "최", "정", "강", "조", "윤", "장", "임", "한", "오", "서", "신", "권", "황", "안", "송", "류", "홍"}
+	givenNames    = []string{"민준", "서연", "도윤", "서윤", "시우", "지우", "하준", "하은", "주원", "지민", "지호", "수아", "준서", "지유", "현우", "채원", "예준", "윤서", "건우", "다은"}
+	familyNamesEn = []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Miller", "Davis", "Wilson", "Anderson", "Taylor", "Thomas", "Moore", "Martin", "Jackson", "White", "Harris", "Clark", "Lewis", "Walker", "Young"}
+	givenNamesEn  = []string{"James", "Mary", "John", "Linda", "Robert", "Susan", "Michael", "Karen", "David", "Nancy", "Daniel", "Lisa", "Paul", "Emily", "Mark", "Sarah", "Steven", "Laura", "Kevin", "Anna"}
+	emailDomains  = []string{"example.com", "example.net", "example.org"}
+	districts     = []string{"서울특별시 종로구", "서울특별시 강남구", "서울특별시 마포구", "부산광역시 해운대구", "대구광역시 수성구", "인천광역시 연수구", "광주광역시 서구", "대전광역시 유성구", "울산광역시 남구", "경기도 성남시 분당구", "경기도 수원시 영통구", "강원도 춘천시", "충청북도 청주시 상당구", "전라북도 전주시 완산구", "경상남도 창원시 성산구", "제주특별자치도 제주시"}
+	roads         = []string{"중앙로", "번영로", "평화로", "시청로", "문화로", "대학로", "공원로", "역전로", "신촌로", "한강로", "행복로", "희망로"}
+	companyStems  = []string{"한빛", "새온", "누리", "다온", "가람", "미래", "하늘", "푸른", "대한", "동방", "태양", "우리"}
+	industries    = []string{"전자", "물산", "산업", "건설", "정보통신", "식품", "제약", "화학", "유통", "모빌리티"}
+)
+
+// pick selects an entry by the n-th 4 bytes of digest
+func pick(entries []string, sum []byte, n int) string {
+	offset := (n * 4) % (len(sum) - 3)
+	return entries[binary.BigEndian.Uint32(sum[offset:offset+4])%uint32(len(entries))]
+}
+
+var gBundledDictionaries = map[string]func([]byte) string{
+	"name": func(sum []byte) string {
+		return pick(familyNames, sum, 0) + pick(givenNames, sum, 1)
+	},
+	"name_en": func(sum []byte) string {
+		return pick(givenNamesEn, sum, 0) + " " + pick(familyNamesEn, sum, 1)
+	},
+	"email": func(sum []byte) string {
+		number := binary.BigEndian.Uint32(sum[8:12]) % 1000
+		return strings.ToLower(pick(givenNamesEn, sum, 0)+"."+pick(familyNamesEn, sum, 1)) + strconv.Itoa(int(number)) + "@" + pick(emailDomains, sum, 3)
+	},
+	"address": func(sum []byte) string {
+		number := binary.BigEndian.Uint32(sum[8:12])%300 + 1
+		return pick(districts, sum, 0) + " " + pick(roads, sum, 1) + " " + strconv.Itoa(int(number))
+	},
+	"company": func(sum []byte) string {
+		return pick(companyStems, sum, 0) + pick(industries, sum, 1) + "(주)"
+	},
+}
+
+func validateSubstitutingOptions(options model.AnoOption) error {
+	if options.Dictionary == "" {
+		return newOptionError("dictionary", "")
+	} else if options.Key == "" && options.KeyRef == "" {
+		// Unkeyed hmac can be reversed by dictionary attack
+		return newOptionError("key", "key or keyRef is required")
+	}
+	switch options.Digest {
+	case "sha256", "md5", "":
+		return nil
+	default:
+		return newOptionError("digest", "unknown digest")
+	}
+}
+
+func BuildSubstitutingFunc(options model.AnoOption) func(string) string {
+	return buildOrError(newSubstitutingFunc(options))
+}
+
+// newSubstitutingFunc replaces each value with a fake value selected by hmac of the value (same value, same fake value)
+func newSubstitutingFunc(options model.AnoOption) (func(string) (string, error), error) {
+	if err := validateSubstitutingOptions(options); err != nil {
+		return nil, err
+	}
+
+	// Key (referenced key is loaded from the key store)
+	key := []byte(options.Key)
+	if options.KeyRef != "" {
+		var err error
+		if key, err = loadReferencedKey(options.KeyRef); err != nil {
+			return nil, err
+		}
+	}
+
+	var mac hash.Hash
+	if options.Digest == "md5" {
+		mac = hmac.New(md5.New, key)
+	} else {
+		mac = hmac.New(sha256.New, key)
+	}
+
+	// Bundled dictionaries are found first (user-supplied dictionaries are loaded from the store)
+	substitute, exists := gBundledDictionaries[options.Dictionary]
+	if !exists {
+		if gDictionaryStore == nil {
+			return nil, errors.New("No dictionary store was registered")
+		}
+		entries, err := gDictionaryStore.LoadDictionary(options.Dictionary)
+		if err != nil {
+			return nil, err
+		}
+		substitute = func(sum []byte) string {
+			return entries[binary.BigEndian.Uint64(sum[:8])%uint64(len(entries))]
+		}
+	}
+
+	return func(inString string) (string, error) {
+		if inString == "" {
+			return "", nil
+		}
+		mac.Write([]byte(inString))
+		defer mac.Reset()
+		return substitute(mac.Sum(nil)), nil
+	}, nil
+}
@@ -0,0 +1,167 @@
+package did
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strconv"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+const (
+	tokenAlphabet      = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	tokenDefaultLength = 16
+	tokenDefaultDomain = "default"
+)
+
+// TokenVault stores the mapping between tokens and original values (by token domain)
+type TokenVault interface {
+	// LoadToken returns the token mapped to the value (empty string if not exists)
+	LoadToken(domain string, value string) (string, error)
+	// StoreToken stores a new mapping and returns the token actually mapped to the value
+	StoreToken(domain string, value string, token string) (string, error)
+	// LoadValue returns the original value mapped to the token
+	LoadValue(domain string, token string) (string, error)
+}
+
+var gTokenVault TokenVault
+
+// SetTokenVault sets the vault used by tokenization
+func SetTokenVault(vault TokenVault) {
+	gTokenVault = vault
+}
+
+func generateToken(length int) (string, error) {
+	token := make([]byte, length)
+	max := big.NewInt(int64(len(tokenAlphabet)))
+	for i := range token {
+		index, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		token[i] = tokenAlphabet[index.Int64()]
+	}
+	return string(token), nil
+}
+
+func tokenDomain(options model.AnoOption) string {
+	if options.Domain == "" {
+		return tokenDefaultDomain
+	}
+	return options.Domain
+}
+
+func tokenLength(options model.AnoOption) (int, error) {
+	if options.Length == "" {
+		return tokenDefaultLength, nil
+	}
+	value, err := strconv.ParseInt(options.Length, 10, 0)
+	if err != nil || value < 8 {
+		return 0, newOptionError("length", "at least 8")
+	}
+	return int(value), nil
+}
+
+func validateTokenizingOptions(options model.AnoOption) error {
+	_, err := tokenLength(options)
+	return err
+}
+
+// temporaryToken derives a token that is not stored from the value (same value, same token in read-only processing)
+func temporaryToken(domain string, value string) string {
+	hash := sha256.Sum256([]byte(domain + "\x00" + value))
+	return hex.EncodeToString(hash[:])
+}
+
+func BuildTokenizingFunc(options model.AnoOption) func(string) string {
+	return buildOrError(tokenBuilder{}.Build(model.AnoMethodOption{Method: "tokenization", Options: options}))
+}
+
+// newTokenizingFunc issues tokens (with read-only row, a temporary token is used for the value that has no token)
+func newTokenizingFunc(options model.AnoOption) (func(string, Row) (string, error), error) {
+	length, err := tokenLength(options)
+	if err != nil {
+		return nil, err
+	} else if gTokenVault == nil {
+		return nil, errors.New("No token vault was registered")
+	}
+	domain := tokenDomain(options)
+
+	// Tokens already issued in this process (avoid repeated vault access)
+	issued := make(map[string]string)
+	return func(inString string, row Row) (string, error) {
+		if inString == "" {
+			return "", nil
+		} else if token, ok := issued[inString]; ok {
+			return token, nil
+		}
+		// Find the token issued before (same input returns same token in a domain)
+		token, err := gTokenVault.LoadToken(domain, inString)
+		if err != nil {
+			return "", err
+		} else if token == "" && row.readOnly {
+			return temporaryToken(domain, inString), nil
+		}
+		// Issue a new token (retry if the random token collides with another one)
+		for retry := 0; token == "" && retry < 3; retry++ {
+			candidate, err := generateToken(length)
+			if err != nil {
+				return "", err
+			}
+			if token, err = gTokenVault.StoreToken(domain, inString, candidate); err != nil {
+				return "", err
+			}
+		}
+		if token == "" {
+			return "", errors.New("tokenization error (token collision)")
+		}
+		issued[inString] = token
+		return token, nil
+	}, nil
+}
+
+// tokenBuilder builds the tokenization (tokens are not issued for read-only rows)
+type tokenBuilder struct{}
+
+func (tokenBuilder) Validate(step model.AnoMethodOption) error {
+	return validateTokenizingOptions(step.Options)
+}
+
+func (tokenBuilder) Build(step model.AnoMethodOption) (func(string) (string, error), error) {
+	fn, err := newTokenizingFunc(step.Options)
+	if err != nil {
+		return nil, err
+	}
+	return func(inString string) (string, error) {
+		return fn(inString, Row{})
+	}, nil
+}
+
+func (tokenBuilder) BuildRow(step model.AnoMethodOption) (func(string, Row) (string, error), error) {
+	return newTokenizingFunc(step.Options)
+}
+
+func Detokenize(domain string, tokens []string) ([]string, error) {
+	if gTokenVault == nil {
+		return nil, errors.New("No token vault was registered")
+	}
+	if domain == "" {
+		domain = tokenDefaultDomain
+	}
+	values := make([]string, len(tokens))
+	for i, token := range tokens {
+		if token == "" {
+			continue
+		}
+		value, err := gTokenVault.LoadValue(domain, token)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,76 @@
+package did
+
+import (
+	"testing"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+// testTokenVault is a token vault in memory (counts stored tokens)
+type testTokenVault struct {
+	tokens map[string]string
+	stored int
+}
+
+func (v *testTokenVault) LoadToken(domain string, value string) (string, error) {
+	return v.tokens[domain+"/"+value], nil
+}
+
+func (v *testTokenVault) StoreToken(domain string, value string, token string) (string, error) {
+	if existing, ok := v.tokens[domain+"/"+value]; ok {
+		return existing, nil
+	}
+	v.tokens[domain+"/"+value] = token
+	v.stored++
+	return token, nil
+}
+
+func (v *testTokenVault) LoadValue(domain string, token string) (string, error) {
+	for key, value := range v.tokens {
+		if value == token && key[:len(domain)+1] == domain+"/" {
+			return key[len(domain)+1:], nil
+		}
+	}
+	return "", nil
+}
+
+func TestTokenReadOnly(t *testing.T) {
+	vault := &testTokenVault{tokens: map[string]string{"default/kim": "TOKEN-KIM"}}
+	SetTokenVault(vault)
+	defer SetTokenVault(nil)
+
+	step := model.AnoMethodOption{Method: "tokenization"}
+	readOnly, err := tokenBuilder{}.BuildRow(step)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := Row{}.ReadOnly()
+
+	// Issued token is used, and no token is issued for a new value
+	if token, _ := readOnly("kim", row); token != "TOKEN-KIM" {
+		t.Errorf("token(kim) = %s, want TOKEN-KIM", token)
+	}
+	first, _ := readOnly("lee", row)
+	second, _ := readOnly("lee", row)
+	other, _ := readOnly("park", row)
+	if first == "" || first != second || first == other {
+		t.Errorf("temporary tokens = %s, %s, %s", first, second, other)
+	}
+	if vault.stored != 0 {
+		t.Errorf("%d tokens are stored in read-only processing", vault.stored)
+	}
+
+	// Tokens are issued without read-only row
+	fn, err := tokenBuilder{}.Build(step)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := fn("lee")
+	if vault.stored != 1 || len(token) != tokenDefaultLength {
+		t.Errorf("token(lee) = %s, %d tokens are stored", token, vault.stored)
+	}
+	if values, _ := Detokenize("", []string{token}); values[0] != "lee" {
+		t.Errorf("detokenize(%s) = %v, want lee", token, values)
+	}
+}
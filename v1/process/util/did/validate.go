@@ -0,0 +1,90 @@
+package did
+
+import (
+	"sort"
+
+	// Model
+	model "privacydam-go/v1/core/model"
+)
+
+// ValidateOptions verifies de-identification options of all columns (return OptionErrors if there are invalid options)
+func ValidateOptions(options map[string]model.AnoParamOption) error {
+	// Sort columns (stable result)
+	columns := make([]string, 0, len(options))
+	for column := range options {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	result := OptionErrors{}
+	for _, column := range columns {
+		option := options[column]
+		switch option.OnError {
+		case "", "null", "suppress", "replace", "abort":
+		default:
+			result = append(result, &OptionError{Column: column, Field: "onError", Reason: "unknown error policy"})
+		}
+		switch option.Role {
+		case "", "quasi_identifier", "sensitive", "identifier", "insensitive":
+		default:
+			result = append(result, &OptionError{Column: column, Field: "role", Reason: "unknown column role"})
+		}
+		for i, step := range Steps(option) {
+			err := validateStep(step)
+			if err == nil && option.KeyColumn == "" {
+				// Subject key column is required by some methods
+				if builder, _ := Lookup(step.Method); builder != nil {
+					if _, ok := builder.(subjectBuilder); ok {
+						err = &OptionError{Method: step.Method, Field: "keyColumn", Reason: "required by method"}
+					}
+				}
+			}
+			if err == nil {
+				continue
+			}
+			// Set location of the error
+			err.Column = column
+			if len(option.Pipeline) > 0 {
+				err.Step = i + 1
+			}
+			result = append(result, err)
+		}
+		// Fallback options (coarser generalization for rows in small classes)
+		for i, step := range option.Fallback {
+			err := validateStep(step)
+			if err == nil {
+				continue
+			}
+			err.Column = column
+			err.Step = i + 1
+			err.Field = "fallback." + err.Field
+			result = append(result, err)
+		}
+	}
+
+	if len(result) > 0 {
+		return result
+	}
+	return nil
+}
+
+func validateStep(step model.AnoMethodOption) *OptionError {
+	if step.Method == "" {
+		return &OptionError{Field: "method", Reason: "can not be blank"}
+	}
+	builder, exists := Lookup(step.Method)
+	if !exists {
+		return &OptionError{Method: step.Method, Field: "method", Reason: "unknown method"}
+	}
+	err := builder.Validate(step)
+	if err == nil {
+		return nil
+	}
+	// Wrap an error returned by custom builder
+	optionErr, ok := err.(*OptionError)
+	if !ok {
+		optionErr = &OptionError{Field: "options", Reason: err.Error()}
+	}
+	optionErr.Method = step.Method
+	return optionErr
+}
@@ -2,6 +2,12 @@ package kAno
 
 import (
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	// Model
+	"privacydam-go/v1/core/model"
 )
 
 type anoEncoder struct {
@@ -61,6 +67,17 @@ type AnoTester struct {
 	finalEncoder anoEncoder
 	targetKValue int
 	evalFields   []bool
+	// sensitive values distribution (for l-diversity, t-closeness)
+	sensitiveFields []bool
+	classDist       map[int][]map[string]int
+	totalDist       []map[string]int
+	targetLValue    int
+	targetEntropyL  float64
+	targetTValue    float64
+	// equivalence class and sequence number of each row (for risk report, small class suppression)
+	rowClasses []int
+	rowSeqs    []int64
+	classSizes map[int]int
 }
 
 func (t *AnoTester) New(length int, kValue int) {
@@ -74,6 +91,9 @@ func (t *AnoTester) New(length int, kValue int) {
 	}
 	t.finalEncoder.init()
 	t.targetKValue = kValue
+	t.sensitiveFields = make([]bool, length)
+	t.classDist = make(map[int][]map[string]int)
+	t.totalDist = make([]map[string]int, length)
 }
 func (t *AnoTester) SetEvalFields(fields []bool) {
 	for i, v := range fields {
@@ -83,6 +103,11 @@ func (t *AnoTester) SetEvalFields(fields []bool) {
 	}
 }
 func (t *AnoTester) AddStrings(strList []string) int {
+	return t.AddRow(int64(len(t.rowClasses)), strList)
+}
+
+// AddRow adds a row with its sequence number (reported as the row of risk report)
+func (t *AnoTester) AddRow(seq int64, strList []string) int {
 	encoded := make([]int, 0)
 	for i, v := range strList {
 		if t.evalFields[i] {
@@ -91,7 +116,11 @@ func (t *AnoTester) AddStrings(strList []string) int {
 		}
 	}
 	//fmt.Printf("%v\n", encoded)
-	return t.finalEncoder.add(fmt.Sprintf("%v", encoded))
+	class := t.finalEncoder.add(fmt.Sprintf("%v", encoded))
+	t.addSensitive(class, strList)
+	t.rowClasses = append(t.rowClasses, class)
+	t.rowSeqs = append(t.rowSeqs, seq)
+	return class
 }
 func (t *AnoTester) Eval() (bool, int) {
 	actValue := t.finalEncoder.getMinFreq()
@@ -103,3 +132,238 @@ func (t *AnoTester) Eval() (bool, int) {
 		return true, actValue
 	}
 }
+
+// SetSensitiveFields sets the fields of sensitive attributes (for l-diversity, t-closeness)
+func (t *AnoTester) SetSensitiveFields(fields []bool) {
+	for i, v := range fields {
+		if i < len(t.sensitiveFields) {
+			t.sensitiveFields[i] = v
+			if v && t.totalDist[i] == nil {
+				t.totalDist[i] = make(map[string]int)
+			}
+		}
+	}
+}
+
+// SetDiversityTargets sets the targets of distinct l-diversity, entropy l-diversity and t-closeness (0 is not evaluated)
+func (t *AnoTester) SetDiversityTargets(lValue int, entropyLValue float64, tValue float64) {
+	t.targetLValue = lValue
+	t.targetEntropyL = entropyLValue
+	t.targetTValue = tValue
+}
+func (t *AnoTester) addSensitive(class int, strList []string) {
+	dist, ok := t.classDist[class]
+	for i, v := range strList {
+		if i >= len(t.sensitiveFields) || !t.sensitiveFields[i] {
+			continue
+		}
+		if !ok {
+			dist = make([]map[string]int, len(t.sensitiveFields))
+			t.classDist[class] = dist
+			ok = true
+		}
+		if dist[i] == nil {
+			dist[i] = make(map[string]int)
+		}
+		dist[i][v]++
+		t.totalDist[i][v]++
+	}
+}
+
+// EvalDistinctL evaluates distinct l-diversity (minimum number of distinct sensitive values in a class)
+func (t *AnoTester) EvalDistinctL() (bool, int) {
+	actValue := 0
+	for _, dist := range t.classDist {
+		for _, values := range dist {
+			if values != nil && (actValue == 0 || len(values) < actValue) {
+				actValue = len(values)
+			}
+		}
+	}
+	return actValue >= t.targetLValue, actValue
+}
+
+// EvalEntropyL evaluates entropy l-diversity (minimum exp(entropy) of sensitive values in a class)
+func (t *AnoTester) EvalEntropyL() (bool, float64) {
+	actValue := math.Inf(1)
+	for _, dist := range t.classDist {
+		for _, values := range dist {
+			if values == nil {
+				continue
+			}
+			total := 0
+			for _, count := range values {
+				total += count
+			}
+			entropy := 0.0
+			for _, count := range values {
+				p := float64(count) / float64(total)
+				entropy -= p * math.Log(p)
+			}
+			actValue = math.Min(actValue, math.Exp(entropy))
+		}
+	}
+	if math.IsInf(actValue, 1) {
+		actValue = 0
+	}
+	return actValue >= t.targetEntropyL, actValue
+}
+
+// EvalTCloseness evaluates t-closeness (maximum EMD between the distribution of a class and the whole data)
+func (t *AnoTester) EvalTCloseness() (bool, float64) {
+	actValue := 0.0
+	for i, total := range t.totalDist {
+		if total == nil {
+			continue
+		}
+		values, numeric := sortSensitiveValues(total)
+		totalCount := 0
+		for _, count := range total {
+			totalCount += count
+		}
+		for _, dist := range t.classDist {
+			if dist[i] == nil {
+				continue
+			}
+			classCount := 0
+			for _, count := range dist[i] {
+				classCount += count
+			}
+			actValue = math.Max(actValue, earthMoverDistance(values, numeric, dist[i], classCount, total, totalCount))
+		}
+	}
+	// (tolerance for rounding error of floating point)
+	return t.targetTValue == 0 || actValue <= t.targetTValue+1e-9, actValue
+}
+
+// sortSensitiveValues sorts values (numerical order if all values are numbers)
+func sortSensitiveValues(dist map[string]int) ([]string, bool) {
+	values := make([]string, 0, len(dist))
+	numeric := true
+	for value := range dist {
+		values = append(values, value)
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			numeric = false
+		}
+	}
+	if numeric {
+		sort.Slice(values, func(i, j int) bool {
+			a, _ := strconv.ParseFloat(values[i], 64)
+			b, _ := strconv.ParseFloat(values[j], 64)
+			return a < b
+		})
+	} else {
+		sort.Strings(values)
+	}
+	return values, numeric
+}
+
+// earthMoverDistance computes EMD (ordered distance for numerical values, equal distance for categorical values)
+func earthMoverDistance(values []string, numeric bool, class map[string]int, classCount int, total map[string]int, totalCount int) float64 {
+	distance, cumulative := 0.0, 0.0
+	for _, value := range values {
+		diff := float64(class[value])/float64(classCount) - float64(total[value])/float64(totalCount)
+		if numeric {
+			cumulative += diff
+			distance += math.Abs(cumulative)
+		} else {
+			distance += math.Abs(diff)
+		}
+	}
+	if numeric {
+		if len(values) < 2 {
+			return 0
+		}
+		return distance / float64(len(values)-1)
+	}
+	return distance / 2
+}
+
+// RiskReport computes re-identification risk (journalist and marketer risk are estimated by the sampling fraction)
+func (t *AnoTester) RiskReport(samplingFraction float64, limit int) model.RiskReport {
+	if samplingFraction <= 0 || samplingFraction > 1 {
+		samplingFraction = 1
+	}
+	report := model.RiskReport{
+		Records:            int64(len(t.rowClasses)),
+		SamplingFraction:   samplingFraction,
+		ClassSizes:         []model.ClassSizeBin{},
+		HighestRiskRecords: []model.RiskRecord{},
+	}
+	if len(t.rowClasses) == 0 {
+		return report
+	}
+
+	classSizes := t.sizeOfClasses()
+	report.Classes = int64(len(classSizes))
+
+	// Histogram of class sizes
+	histogram := make(map[int]int64)
+	minSize := len(t.rowClasses)
+	for _, size := range classSizes {
+		histogram[size]++
+		if size < minSize {
+			minSize = size
+		}
+	}
+	for size, classes := range histogram {
+		report.ClassSizes = append(report.ClassSizes, model.ClassSizeBin{Size: int64(size), Classes: classes, Records: int64(size) * classes})
+	}
+	sort.Slice(report.ClassSizes, func(i, j int) bool {
+		return report.ClassSizes[i].Size < report.ClassSizes[j].Size
+	})
+	report.UniqueRecords = histogram[1]
+	report.UniqueShare = float64(histogram[1]) / float64(report.Records)
+
+	// Prosecutor risk (1 / class size), journalist risk (1 / estimated class size in population)
+	report.ProsecutorRisk = model.RiskMeasure{
+		Highest: 1 / float64(minSize),
+		Average: float64(report.Classes) / float64(report.Records),
+	}
+	report.JournalistRisk = model.RiskMeasure{
+		Highest: math.Min(1, samplingFraction/float64(minSize)),
+		Average: float64(report.Classes) * samplingFraction / float64(report.Records),
+	}
+	report.MarketerRisk = report.JournalistRisk.Average
+
+	// Records in the smallest classes (ordered by class size and sequence number)
+	if limit > 0 {
+		rows := make([]int, len(t.rowClasses))
+		for i := range rows {
+			rows[i] = i
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			a, b := classSizes[t.rowClasses[rows[i]]], classSizes[t.rowClasses[rows[j]]]
+			if a != b {
+				return a < b
+			}
+			return t.rowSeqs[rows[i]] < t.rowSeqs[rows[j]]
+		})
+		if len(rows) > limit {
+			rows = rows[:limit]
+		}
+		for _, row := range rows {
+			size := classSizes[t.rowClasses[row]]
+			report.HighestRiskRecords = append(report.HighestRiskRecords, model.RiskRecord{Row: t.rowSeqs[row], ClassSize: int64(size), Risk: 1 / float64(size)})
+		}
+	}
+	return report
+}
+
+func (t *AnoTester) sizeOfClasses() map[int]int {
+	if t.classSizes == nil || len(t.classSizes) != len(t.finalEncoder.encDict) {
+		t.classSizes = make(map[int]int, len(t.finalEncoder.encDict))
+		for key, class := range t.finalEncoder.encDict {
+			t.classSizes[class] = t.finalEncoder.freqDict[key]
+		}
+	}
+	return t.classSizes
+}
+
+// RowClassSize returns the size of the equivalence class of a row (index in the order of AddStrings, AddRow)
+func (t *AnoTester) RowClassSize(row int) int {
+	if row < 0 || row >= len(t.rowClasses) {
+		return 0
+	}
+	return t.sizeOfClasses()[t.rowClasses[row]]
+}
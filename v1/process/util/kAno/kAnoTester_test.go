@@ -0,0 +1,145 @@
+package kAno
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	// Model
+	"privacydam-go/v1/core/model"
+)
+
+// newTester creates a tester with a quasi-identifier (first column) and a sensitive attribute (second column)
+func newTester(rows [][]string) *AnoTester {
+	tester := new(AnoTester)
+	tester.New(2, 2)
+	tester.SetEvalFields([]bool{true, false})
+	tester.SetSensitiveFields([]bool{false, true})
+	for _, row := range rows {
+		tester.AddStrings(row)
+	}
+	return tester
+}
+
+func almostEqual(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestDiversity(t *testing.T) {
+	cases := []struct {
+		name      string
+		rows      [][]string
+		distinctL int
+		entropyL  float64
+		tValue    float64
+	}{
+		{
+			// Categorical values (equal distance): EMD is the half of the sum of differences
+			name: "categorical",
+			rows: [][]string{
+				{"100", "flu"}, {"100", "flu"}, {"100", "cold"},
+				{"200", "flu"}, {"200", "cancer"}, {"200", "cold"},
+			},
+			distinctL: 2,
+			// exp(-(2/3)ln(2/3) - (1/3)ln(1/3)) = 3 / 2^(2/3)
+			entropyL: 3 / math.Pow(2, 2.0/3),
+			// |2/3 - 1/2| + |1/3 - 1/3| + |0 - 1/6| = 1/3
+			tValue: 1.0 / 6,
+		},
+		{
+			// Ordered values (numerical order, not string order): salary example of t-closeness paper
+			name: "ordered",
+			rows: [][]string{
+				{"A", "3"}, {"A", "4"}, {"A", "5"},
+				{"B", "6"}, {"B", "8"}, {"B", "11"},
+				{"C", "7"}, {"C", "9"}, {"C", "10"},
+			},
+			distinctL: 3,
+			entropyL:  3,
+			// class A: cumulative differences (2, 4, 6, 5, 4, 3, 2, 1, 0) / 9, divided by 8
+			tValue: 0.375,
+		},
+		{
+			// A class with one sensitive value
+			name: "homogeneous",
+			rows: [][]string{
+				{"100", "flu"}, {"100", "flu"},
+				{"200", "flu"}, {"200", "cold"},
+			},
+			distinctL: 1,
+			entropyL:  1,
+			// |1 - 3/4| + |0 - 1/4| = 1/2
+			tValue: 0.25,
+		},
+	}
+	for _, c := range cases {
+		tester := newTester(c.rows)
+		tester.SetDiversityTargets(2, 2, 0.3)
+
+		if passed, distinctL := tester.EvalDistinctL(); distinctL != c.distinctL || passed != (c.distinctL >= 2) {
+			t.Errorf("%s: distinct l = %d, %t, want %d", c.name, distinctL, passed, c.distinctL)
+		}
+		if passed, entropyL := tester.EvalEntropyL(); !almostEqual(entropyL, c.entropyL) || passed != (c.entropyL >= 2) {
+			t.Errorf("%s: entropy l = %f, %t, want %f", c.name, entropyL, passed, c.entropyL)
+		}
+		if passed, tValue := tester.EvalTCloseness(); !almostEqual(tValue, c.tValue) || passed != (c.tValue <= 0.3) {
+			t.Errorf("%s: t = %f, %t, want %f", c.name, tValue, passed, c.tValue)
+		}
+	}
+}
+
+func TestRiskReport(t *testing.T) {
+	tester := newTester(nil)
+	// Rows are added out of the order of sequence number (class a: 3 rows, b: 2 rows, c: 1 row)
+	rows := []struct {
+		seq   int64
+		value string
+	}{
+		{5, "a"}, {4, "b"}, {3, "a"}, {2, "c"}, {1, "b"}, {0, "a"},
+	}
+	for _, row := range rows {
+		tester.AddRow(row.seq, []string{row.value, ""})
+	}
+
+	report := tester.RiskReport(0.5, 3)
+	if report.Records != 6 || report.Classes != 3 || report.UniqueRecords != 1 || !almostEqual(report.UniqueShare, 1.0/6) {
+		t.Errorf("records, classes, unique = %d, %d, %d, %f", report.Records, report.Classes, report.UniqueRecords, report.UniqueShare)
+	}
+	histogram := []model.ClassSizeBin{{Size: 1, Classes: 1, Records: 1}, {Size: 2, Classes: 1, Records: 2}, {Size: 3, Classes: 1, Records: 3}}
+	if !reflect.DeepEqual(report.ClassSizes, histogram) {
+		t.Errorf("class sizes = %v, want %v", report.ClassSizes, histogram)
+	}
+
+	// Prosecutor: 1 / min size, classes / records; journalist, marketer: multiplied by sampling fraction
+	measures := []struct {
+		name   string
+		actual float64
+		want   float64
+	}{
+		{"prosecutor highest", report.ProsecutorRisk.Highest, 1},
+		{"prosecutor average", report.ProsecutorRisk.Average, 0.5},
+		{"journalist highest", report.JournalistRisk.Highest, 0.5},
+		{"journalist average", report.JournalistRisk.Average, 0.25},
+		{"marketer", report.MarketerRisk, 0.25},
+	}
+	for _, m := range measures {
+		if !almostEqual(m.actual, m.want) {
+			t.Errorf("%s risk = %f, want %f", m.name, m.actual, m.want)
+		}
+	}
+
+	// Ordered by class size and sequence number of query result
+	records := []model.RiskRecord{{Row: 2, ClassSize: 1, Risk: 1}, {Row: 1, ClassSize: 2, Risk: 0.5}, {Row: 4, ClassSize: 2, Risk: 0.5}}
+	if !reflect.DeepEqual(report.HighestRiskRecords, records) {
+		t.Errorf("highest risk records = %v, want %v", report.HighestRiskRecords, records)
+	}
+
+	// Invalid sampling fraction is the whole population
+	if report := tester.RiskReport(0, 0); report.SamplingFraction != 1 || !almostEqual(report.JournalistRisk.Highest, 1) || len(report.HighestRiskRecords) != 0 {
+		t.Errorf("sampling fraction 0: %+v", report)
+	}
+	// Empty data
+	if report := newTester(nil).RiskReport(1, 10); report.Records != 0 || len(report.ClassSizes) != 0 {
+		t.Errorf("empty data: %+v", report)
+	}
+}
@@ -3,6 +3,7 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"os"
 	"strconv"
@@ -15,6 +16,7 @@ import (
 	"privacydam-go/v1/core/model"
 	// Util
 	"privacydam-go/v1/core/db"
+	"privacydam-go/v1/process/util/did"
 )
 
 func GenerateApi(ctx context.Context, tracking bool, api model.Api) error {
@@ -48,6 +50,37 @@ func GenerateApi(ctx context.Context, tracking bool, api model.Api) error {
 		return errors.New("Invalid expires (can not be blank)")
 	}
 
+	// Verify export policy
+	switch api.Policy.UnconfiguredColumn {
+	case "", "pass", "drop", "reject":
+	default:
+		return errors.New("Invalid export policy (unconfiguredColumn must be pass, drop or reject)")
+	}
+	if api.Policy.KValue < 0 {
+		return errors.New("Invalid export policy (kValue can not be negative)")
+	}
+	switch api.Policy.Enforcement {
+	case "", "report", "block":
+	default:
+		return errors.New("Invalid export policy (enforcement must be report or block)")
+	}
+	if api.Policy.LValue < 0 || api.Policy.EntropyLValue < 0 {
+		return errors.New("Invalid export policy (lValue and entropyLValue can not be negative)")
+	} else if api.Policy.TValue < 0 || api.Policy.TValue > 1 {
+		return errors.New("Invalid export policy (tValue must be 0 ~ 1)")
+	}
+	switch api.Policy.SmallClass {
+	case "", "keep", "suppress", "generalize":
+	default:
+		return errors.New("Invalid export policy (smallClass must be keep, suppress or generalize)")
+	}
+	// Verify de-identification options
+	if api.QueryContent.DidOptions != "" {
+		if err := ValidateDidOptions(api.QueryContent.DidOptions); err != nil {
+			return err
+		}
+	}
+
 	// Get database object
 	dbInfo, err := db.GetDatabase("internal", nil)
 	if err != nil {
@@ -123,6 +156,25 @@ func GenerateApi(ctx context.Context, tracking bool, api model.Api) error {
 		}
 	}
 
+	if api.Policy != (model.ExportPolicy{}) {
+		// Transform export policy to json
+		rawPolicy, err := json.Marshal(api.Policy)
+		if err != nil {
+			return err
+		}
+		// Execute query (insert export policy)
+		querySyntax := `INSERT INTO api_policy (api_id, policy) VALUE (?, ?)`
+		if dbInfo.Tracking {
+			_, err = tx.ExecContext(subCtx, querySyntax, insertedId, string(rawPolicy))
+		} else {
+			_, err = tx.Exec(querySyntax, insertedId, string(rawPolicy))
+		}
+		// Catch error
+		if err != nil {
+			return err
+		}
+	}
+
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
 		return err
@@ -131,6 +183,21 @@ func GenerateApi(ctx context.Context, tracking bool, api model.Api) error {
 	}
 }
 
+/*
+ * Validate de-identification options (raw JSON format)
+ * <IN> rawOptions (string): de-identification options
+ * <OUT> (error): validation result (did.OptionErrors if options are invalid, nil is valid)
+ */
+func ValidateDidOptions(rawOptions string) error {
+	// Transform to structure
+	var didOptions map[string]model.AnoParamOption
+	if err := json.Unmarshal([]byte(rawOptions), &didOptions); err != nil {
+		return did.OptionErrors{&did.OptionError{Field: "options", Reason: err.Error()}}
+	}
+	// Validate
+	return did.ValidateOptions(didOptions)
+}
+
 func DuplicateCheckForAlias(ctx context.Context, alias string) error {
 	// Get database object
 	dbInfo, err := db.GetDatabase("internal", nil)
@@ -189,3 +256,157 @@ func GenerateSource(ctx context.Context, tracking bool, source model.Source) err
 		return db.CreateConnectionPool(ctx, isTracking, source, true)
 	}
 }
+
+func GenerateHierarchy(ctx context.Context, tracking bool, hierarchy model.Hierarchy) error {
+	var subCtx context.Context = ctx
+	var subSegment *xray.Segment
+	if tracking {
+		subCtx, subSegment = xray.BeginSubsegment(ctx, "Generate hierarchy")
+		defer subSegment.Close(nil)
+	}
+
+	// Verify hierarchy
+	if hierarchy.Name == "" {
+		return errors.New("Invalid hierarchy name (can not be blank)")
+	} else if len(hierarchy.Nodes) == 0 {
+		return errors.New("Invalid hierarchy (no nodes)")
+	}
+
+	// Get database object
+	dbInfo, err := db.GetDatabase("internal", nil)
+	if err != nil {
+		return err
+	}
+
+	// Begin transaction
+	tx, err := dbInfo.Instance.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Execute query (insert hierarchy)
+	var result sql.Result
+	querySyntax := `INSERT INTO hierarchy (hierarchy_name) VALUE (?)`
+	if dbInfo.Tracking {
+		result, err = tx.ExecContext(subCtx, querySyntax, hierarchy.Name)
+	} else {
+		result, err = tx.Exec(querySyntax, hierarchy.Name)
+	}
+	// Catch error
+	if err != nil {
+		return err
+	}
+	// Extract inserted id
+	insertedId, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	// Prepare query (insert hierarchy nodes)
+	var stmt *sql.Stmt
+	querySyntax = `INSERT INTO hierarchy_node (hierarchy_id, node_value, parent_value) VALUE (?, ?, NULLIF(?, ''))`
+	if dbInfo.Tracking {
+		stmt, err = tx.PrepareContext(subCtx, querySyntax)
+	} else {
+		stmt, err = tx.Prepare(querySyntax)
+	}
+	// Catch error
+	if err != nil {
+		return err
+	}
+
+	// Execute query (insert hierarchy nodes)
+	for _, node := range hierarchy.Nodes {
+		var err error
+		if dbInfo.Tracking {
+			_, err = stmt.ExecContext(subCtx, insertedId, node.Value, node.Parent)
+		} else {
+			_, err = stmt.Exec(insertedId, node.Value, node.Parent)
+		}
+		// Catch error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Commit transaction
+	return tx.Commit()
+}
+
+func GenerateDictionary(ctx context.Context, tracking bool, dictionary model.Dictionary) error {
+	var subCtx context.Context = ctx
+	var subSegment *xray.Segment
+	if tracking {
+		subCtx, subSegment = xray.BeginSubsegment(ctx, "Generate dictionary")
+		defer subSegment.Close(nil)
+	}
+
+	// Verify dictionary
+	if dictionary.Name == "" {
+		return errors.New("Invalid dictionary name (can not be blank)")
+	} else if len(dictionary.Entries) == 0 {
+		return errors.New("Invalid dictionary (no entries)")
+	}
+
+	// Get database object
+	dbInfo, err := db.GetDatabase("internal", nil)
+	if err != nil {
+		return err
+	}
+
+	// Begin transaction
+	tx, err := dbInfo.Instance.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Execute query (insert dictionary)
+	var result sql.Result
+	querySyntax := `INSERT INTO dictionary (dictionary_name) VALUE (?)`
+	if dbInfo.Tracking {
+		result, err = tx.ExecContext(subCtx, querySyntax, dictionary.Name)
+	} else {
+		result, err = tx.Exec(querySyntax, dictionary.Name)
+	}
+	// Catch error
+	if err != nil {
+		return err
+	}
+	// Extract inserted id
+	insertedId, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	// Prepare query (insert dictionary entries)
+	var stmt *sql.Stmt
+	querySyntax = `INSERT INTO dictionary_entry (dictionary_id, entry_value) VALUE (?, ?)`
+	if dbInfo.Tracking {
+		stmt, err = tx.PrepareContext(subCtx, querySyntax)
+	} else {
+		stmt, err = tx.Prepare(querySyntax)
+	}
+	// Catch error
+	if err != nil {
+		return err
+	}
+
+	// Execute query (insert dictionary entries)
+	for _, entry := range dictionary.Entries {
+		var err error
+		if dbInfo.Tracking {
+			_, err = stmt.ExecContext(subCtx, insertedId, entry)
+		} else {
+			_, err = stmt.Exec(insertedId, entry)
+		}
+		// Catch error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Commit transaction
+	return tx.Commit()
+}